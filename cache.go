@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/secim/src/client"
+	"github.com/secim/src/logx"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+)
+
+// cacheKomutu http yanit onbellegini listeler, temizler veya gecersiz kilar
+//
+//	cache ls                  kayitlari, yaslarini ve hash durumlarini listeler
+//	cache prune               suresi dolmus ve bozuk kayitlari siler
+//	cache invalidate <parca>  url'i parcayi iceren kayitlari siler (ornek: getIlceList)
+//	cache clear               tum http onbellegini siler
+func cacheKomutu(args []string) {
+	fs := flag.NewFlagSet("cache", flag.ExitOnError)
+	kok := fs.String("out-root", ".", "onbellegi yazan komutun -out-root'u")
+	dir := fs.String("dir", "", "http onbellek dizini (bos = <out-root>/"+httpCacheDir+")")
+	parseFlags(fs, args)
+	if *dir == "" {
+		*dir = filepath.Join(*kok, httpCacheDir)
+	}
+	dc := client.NewDiskCache(*dir, nil, client.DefaultCacheRules)
+	switch sub := fs.Arg(0); sub {
+	case "ls", "":
+		l, err := dc.Entries()
+		if err != nil {
+			logx.Fatal("cannot list cache", logx.Err(err))
+		}
+		now := time.Now()
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		must(fmt.Fprintln(tw, "DURUM\tYAS\tKALAN\tBOYUT\tSHA256\tURL"))
+		for _, e := range l {
+			durum := "taze"
+			if dc.Verify(e) != nil {
+				durum = "bozuk"
+			} else if e.Expired(now) {
+				durum = "eski"
+			}
+			must(fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%.12s\t%s\n", durum,
+				now.Sub(e.FetchedAt).Truncate(time.Second), e.ExpiresAt.Sub(now).Truncate(time.Second),
+				e.Size, e.SHA256, e.URL))
+		}
+		if err = tw.Flush(); err != nil {
+			logx.Fatal("cannot write to stdout", logx.Err(err))
+		}
+		fmt.Printf("%d kayit\n", len(l))
+	case "prune":
+		n, err := dc.Prune()
+		if err != nil {
+			logx.Fatal("cannot prune cache", logx.Err(err))
+		}
+		fmt.Printf("%d kayit silindi\n", n)
+	case "invalidate", "clear":
+		match := fs.Arg(1)
+		if sub == "invalidate" && match == "" {
+			logx.Fatal("kullanim: cache invalidate <url parcasi>")
+		}
+		n, err := dc.Invalidate(match)
+		if err != nil {
+			logx.Fatal("cannot invalidate cache", logx.Err(err))
+		}
+		fmt.Printf("%d kayit silindi\n", n)
+	default:
+		logx.Fatal("bilinmeyen cache komutu (ls, prune, invalidate, clear)", logx.F("komut", sub))
+	}
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/secim/src/logx"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// kimlikSutunlari sandik kimligi olusturulurken bakilan sutunlar.
+// addRow'un bilinmeyen api alanlarindan urettigi adlarla ayni olmali.
+var kimlikSutunlari = []string{
+	"IL ADI", "ILCE ADI", "MUHTARLIK ADI", "CEZAEVI ADI",
+	"ULKE ADI", "DIS TEMSILCILIK ADI", "GUMRUK ADI", "SANDIK RUMUZ", "SANDIK NO",
+}
+
+// snapshot diff icin bellege okunmus bir cikti dosyasi
+type snapshot struct {
+	fn   string
+	cols []string
+	// anahtarli ilk sutun sandik anahtari (yeni ciktilar); eskilerde sira numarasi (#)
+	anahtarli bool
+	satirlar  []snapshotSatiri
+	// keys, rows ve dupes anahtarla ile doldurulur
+	keys  []string
+	rows  map[string]map[string]string
+	dupes int
+}
+
+// snapshotSatiri dosyadaki sirasiyla bir satir ve varsa anahtar sutunu
+type snapshotSatiri struct {
+	anahtar string
+	row     map[string]string
+}
+
+// sandikKimligi once api'nin sandik id'sine, yoksa birim adlari + sandik no'ya bakar
+func sandikKimligi(row map[string]string) string {
+	if id := row["SANDIK ID"]; id != "" {
+		return "id:" + id
+	}
+	parts := make([]string, 0, len(kimlikSutunlari))
+	for _, col := range kimlikSutunlari {
+		if v, ok := row[col]; ok {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+func readSnapshot(fn string) *snapshot {
+	f, err := acSikistirilmis(fn)
+	if err != nil {
+		logx.Fatal("cannot open file", logx.F("dosya", fn), logx.Err(err))
+	}
+	defer func() { _ = f.Close() }()
+	r := csv.NewReader(f)
+	// sutunlar %q ile yazildigi icin csv'ye tam uymayabilir
+	r.LazyQuotes = true
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		logx.Fatal("cannot read header", logx.F("dosya", fn), logx.Err(err))
+	}
+	s := &snapshot{fn: fn, cols: header[1:], anahtarli: header[0] == anahtarSutunu}
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			logx.Fatal("cannot read file", logx.F("dosya", fn), logx.Err(err))
+		}
+		row := make(map[string]string, len(s.cols))
+		for i, col := range s.cols {
+			if i+1 < len(rec) {
+				row[col] = rec[i+1]
+			}
+		}
+		ss := snapshotSatiri{row: row}
+		if s.anahtarli {
+			ss.anahtar = rec[0]
+		}
+		s.satirlar = append(s.satirlar, ss)
+	}
+	return s
+}
+
+// anahtarla satirlari anahtarlarina gore hizalar. anahtarli degilse veya
+// satirin anahtari bossa sandikKimligi kullanilir; ilk gelen satir kalir.
+func (s *snapshot) anahtarla(anahtarli bool) {
+	s.keys, s.rows, s.dupes = nil, make(map[string]map[string]string, len(s.satirlar)), 0
+	for _, ss := range s.satirlar {
+		key := ss.anahtar
+		if !anahtarli || key == "" {
+			key = sandikKimligi(ss.row)
+		}
+		if _, ok := s.rows[key]; ok {
+			s.dupes++
+			continue
+		}
+		s.rows[key] = ss.row
+		s.keys = append(s.keys, key)
+	}
+}
+
+type sutunFark struct {
+	Sutun string `json:"sutun"`
+	Eski  string `json:"eski"`
+	Yeni  string `json:"yeni"`
+	Fark  *int   `json:"fark,omitempty"`
+}
+
+type sandikFark struct {
+	Anahtar string      `json:"anahtar"`
+	Il      string      `json:"il"`
+	Ilce    string      `json:"ilce"`
+	Durum   string      `json:"durum"`
+	Farklar []sutunFark `json:"farklar,omitempty"`
+}
+
+type bolgeOzet struct {
+	Il      string         `json:"il"`
+	Ilce    string         `json:"ilce"`
+	Eklenen int            `json:"eklenen"`
+	Silinen int            `json:"silinen"`
+	Degisen int            `json:"degisen"`
+	Farklar map[string]int `json:"farklar"`
+}
+
+type diffSonuc struct {
+	Eski            string       `json:"eski"`
+	Yeni            string       `json:"yeni"`
+	EklenenSutunlar []string     `json:"eklenenSutunlar,omitempty"`
+	SilinenSutunlar []string     `json:"silinenSutunlar,omitempty"`
+	TekrarlananEski int          `json:"tekrarlananEski"`
+	TekrarlananYeni int          `json:"tekrarlananYeni"`
+	Degismeyen      int          `json:"degismeyen"`
+	Sandiklar       []sandikFark `json:"sandiklar,omitempty"`
+	Ozetler         []*bolgeOzet `json:"ozetler"`
+}
+
+// diffKomutu iki cikti dosyasini sandik kimligine gore hizalayip farklari raporlar
+func diffKomutu(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := fs.String("format", "text", "cikti formati: text veya json")
+	ozet := fs.Bool("ozet", false, "sadece il/ilce ozetlerini yaz")
+	parseFlags(fs, args)
+	if fs.NArg() != 2 {
+		logx.Fatal("kullanim: diff [-format text|json] [-ozet] <eski.csv[.gz|.zst]> <yeni.csv[.gz|.zst]>")
+	}
+	d := diffSnapshots(readSnapshot(fs.Arg(0)), readSnapshot(fs.Arg(1)))
+	if *ozet {
+		d.Sandiklar = nil
+	}
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(d); err != nil {
+			logx.Fatal("cannot encode diff", logx.Err(err))
+		}
+	case "text":
+		d.fprintText(os.Stdout)
+	default:
+		logx.Fatal("gecersiz format", logx.F("format", *format))
+	}
+}
+
+func diffSnapshots(eski, yeni *snapshot) *diffSonuc {
+	// iki dosya ayni anahtarla hizalanmali; sadece biri anahtarliysa
+	// ikisi de sutunlardan uretilen sandik kimligine duser
+	anahtarli := eski.anahtarli && yeni.anahtarli
+	if eski.anahtarli != yeni.anahtarli {
+		logx.Warn("Dosyalardan sadece biri anahtar sutunu iceriyor, sandiklar sutunlardan eslenecek",
+			logx.F("eski", eski.fn), logx.F("yeni", yeni.fn))
+	}
+	eski.anahtarla(anahtarli)
+	yeni.anahtarla(anahtarli)
+	d := &diffSonuc{Eski: eski.fn, Yeni: yeni.fn, TekrarlananEski: eski.dupes, TekrarlananYeni: yeni.dupes}
+	eskiCols, yeniCols := make(map[string]bool), make(map[string]bool)
+	for _, col := range eski.cols {
+		eskiCols[col] = true
+	}
+	for _, col := range yeni.cols {
+		yeniCols[col] = true
+		if !eskiCols[col] {
+			d.EklenenSutunlar = append(d.EklenenSutunlar, col)
+		}
+	}
+	for _, col := range eski.cols {
+		if !yeniCols[col] {
+			d.SilinenSutunlar = append(d.SilinenSutunlar, col)
+		}
+	}
+
+	ozetler := make(map[[2]string]*bolgeOzet)
+	ozetOf := func(row map[string]string) *bolgeOzet {
+		k := [2]string{bolgeIl(row), row["ILCE ADI"]}
+		o, ok := ozetler[k]
+		if !ok {
+			o = &bolgeOzet{Il: k[0], Ilce: k[1], Farklar: make(map[string]int)}
+			ozetler[k] = o
+			d.Ozetler = append(d.Ozetler, o)
+		}
+		return o
+	}
+	// once yeni dosya sirasiyla eklenen / degisenler, sonra silinenler
+	for _, key := range yeni.keys {
+		yr := yeni.rows[key]
+		er, ok := eski.rows[key]
+		sf := sandikFark{Anahtar: key, Il: bolgeIl(yr), Ilce: yr["ILCE ADI"]}
+		o := ozetOf(yr)
+		if !ok {
+			sf.Durum = "eklendi"
+			o.Eklenen++
+			d.Sandiklar = append(d.Sandiklar, sf)
+			continue
+		}
+		for _, col := range yeni.cols {
+			ev, yv := er[col], yr[col]
+			if ev == yv {
+				continue
+			}
+			fark := sutunFark{Sutun: col, Eski: ev, Yeni: yv}
+			if delta, ok := sayiFarki(ev, yv); ok {
+				fark.Fark = &delta
+				o.Farklar[col] += delta
+			}
+			sf.Farklar = append(sf.Farklar, fark)
+		}
+		if len(sf.Farklar) == 0 {
+			d.Degismeyen++
+			continue
+		}
+		sf.Durum = "degisti"
+		o.Degisen++
+		d.Sandiklar = append(d.Sandiklar, sf)
+	}
+	for _, key := range eski.keys {
+		if _, ok := yeni.rows[key]; ok {
+			continue
+		}
+		er := eski.rows[key]
+		ozetOf(er).Silinen++
+		d.Sandiklar = append(d.Sandiklar, sandikFark{Anahtar: key, Il: bolgeIl(er), Ilce: er["ILCE ADI"], Durum: "silindi"})
+	}
+	sort.SliceStable(d.Ozetler, func(i, j int) bool {
+		if d.Ozetler[i].Il != d.Ozetler[j].Il {
+			return d.Ozetler[i].Il < d.Ozetler[j].Il
+		}
+		return d.Ozetler[i].Ilce < d.Ozetler[j].Ilce
+	})
+	return d
+}
+
+// bolgeIl yurt disi ve gumruk dosyalarinda il yerine ulke / gumruk adini kullanir
+func bolgeIl(row map[string]string) string {
+	for _, col := range []string{"IL ADI", "ULKE ADI", "GUMRUK ADI"} {
+		if v := row[col]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// sayiFarki iki hucre de tam sayiysa farki doner; bos hucre 0 sayilir
+func sayiFarki(eski, yeni string) (int, bool) {
+	parse := func(s string) (int, bool) {
+		if s == "" {
+			return 0, true
+		}
+		v, err := strconv.Atoi(s)
+		return v, err == nil
+	}
+	e, eok := parse(eski)
+	y, yok := parse(yeni)
+	return y - e, eok && yok
+}
+
+func (d *diffSonuc) fprintText(w io.Writer) {
+	must(fmt.Fprintf(w, "--- %s\n+++ %s\n", d.Eski, d.Yeni))
+	for _, col := range d.EklenenSutunlar {
+		must(fmt.Fprintf(w, "+ sutun %q\n", col))
+	}
+	for _, col := range d.SilinenSutunlar {
+		must(fmt.Fprintf(w, "- sutun %q\n", col))
+	}
+	if d.TekrarlananEski+d.TekrarlananYeni != 0 {
+		must(fmt.Fprintf(w, "! tekrarlanan sandik: eski %d, yeni %d\n", d.TekrarlananEski, d.TekrarlananYeni))
+	}
+	for _, sf := range d.Sandiklar {
+		switch sf.Durum {
+		case "eklendi":
+			must(fmt.Fprintf(w, "+ %s\n", sf.Anahtar))
+		case "silindi":
+			must(fmt.Fprintf(w, "- %s\n", sf.Anahtar))
+		default:
+			must(fmt.Fprintf(w, "~ %s\n", sf.Anahtar))
+			for _, f := range sf.Farklar {
+				if f.Fark != nil {
+					must(fmt.Fprintf(w, "    %s: %s -> %s (%+d)\n", f.Sutun, f.Eski, f.Yeni, *f.Fark))
+				} else {
+					must(fmt.Fprintf(w, "    %s: %q -> %q\n", f.Sutun, f.Eski, f.Yeni))
+				}
+			}
+		}
+	}
+	must(fmt.Fprintln(w, "\nOzet:"))
+	for _, o := range d.Ozetler {
+		if o.Eklenen+o.Silinen+o.Degisen == 0 {
+			continue
+		}
+		must(fmt.Fprintf(w, "%s / %s: +%d -%d ~%d\n", o.Il, o.Ilce, o.Eklenen, o.Silinen, o.Degisen))
+		cols := make([]string, 0, len(o.Farklar))
+		for col := range o.Farklar {
+			cols = append(cols, col)
+		}
+		sort.Strings(cols)
+		for _, col := range cols {
+			if o.Farklar[col] != 0 {
+				must(fmt.Fprintf(w, "    %s: %+d\n", col, o.Farklar[col]))
+			}
+		}
+	}
+	must(fmt.Fprintf(w, "%d sandik degismedi\n", d.Degismeyen))
+}
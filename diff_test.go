@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// eski dosya sira numarali (#), yeni dosya anahtarli; ikisi de sutunlardan
+// uretilen kimlikle hizalanmali, yoksa butun sandiklar silinip eklenmis gorunur
+func TestDiffKarisikAnahtar(t *testing.T) {
+	d := diffSnapshots(readSnapshot(filepath.Join("testdata", "diff", "eski.csv")),
+		readSnapshot(filepath.Join("testdata", "diff", "yeni.csv")))
+	durum := make(map[string]string)
+	for _, sf := range d.Sandiklar {
+		durum[sf.Anahtar] = sf.Durum
+	}
+	want := map[string]string{"id:12": "degisti", "id:14": "silindi", "id:15": "eklendi"}
+	if len(durum) != len(want) {
+		t.Fatalf("got %v, want %v", durum, want)
+	}
+	for k, v := range want {
+		if durum[k] != v {
+			t.Errorf("%s: got %q, want %q", k, durum[k], v)
+		}
+	}
+}
+
+// sikistirilmis ciktilar uzantilarina gore acilarak okunmali
+func TestDiffSikistirilmis(t *testing.T) {
+	ham, err := os.ReadFile(filepath.Join("testdata", "diff", "yeni.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, tur := range []string{"gzip", "zstd"} {
+		t.Run(tur, func(t *testing.T) {
+			fn := filepath.Join(dir, "yeni.csv"+sikistirmaUzantisi[tur])
+			f, err := os.Create(fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			z := sikistir(f, tur)
+			if _, err = z.Write(ham); err == nil {
+				err = z.Close()
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = f.Close()
+			s := readSnapshot(fn)
+			if !s.anahtarli || len(s.satirlar) != 4 {
+				t.Errorf("got %d rows (keyed %v) from %s", len(s.satirlar), s.anahtarli, fn)
+			}
+		})
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	fixture := func(ad string) string { return filepath.Join("testdata", "diff", ad) }
+	for _, tc := range []struct {
+		name       string
+		eski, yeni string
+		durumlar   map[string]string
+		tekrar     [2]int
+		degismeyen int
+		eklenenSut []string
+		ozetler    map[string][3]int // il/ilce -> eklenen, silinen, degisen
+		farklar    map[string]int    // ANKARA/ÇANKAYA sutun farklari
+		textIcerir []string
+	}{
+		{
+			name: "eskiYeni", eski: "eski.csv", yeni: "yeni.csv",
+			durumlar:   map[string]string{"id:12": "degisti", "id:14": "silindi", "id:15": "eklendi"},
+			tekrar:     [2]int{1, 0},
+			degismeyen: 2,
+			eklenenSut: []string{"EK ALAN"},
+			ozetler: map[string][3]int{
+				"ANKARA/ÇANKAYA": {0, 0, 1}, "ANKARA/SİNCAN": {0, 0, 0}, "İZMİR/KONAK": {1, 1, 0},
+			},
+			farklar: map[string]int{"A PARTİSİ": 10, "B PARTİSİ": -5},
+			textIcerir: []string{
+				`+ sutun "EK ALAN"`, "! tekrarlanan sandik: eski 1, yeni 0", "~ id:12",
+				"    A PARTİSİ: 80 -> 90 (+10)", "+ id:15", "- id:14", "İZMİR / KONAK: +1 -1 ~0",
+				"2 sandik degismedi",
+			},
+		},
+		{
+			name: "ayni", eski: "yeni.csv", yeni: "yeni.csv",
+			durumlar:   map[string]string{},
+			degismeyen: 4,
+			ozetler: map[string][3]int{
+				"ANKARA/ÇANKAYA": {0, 0, 0}, "ANKARA/SİNCAN": {0, 0, 0}, "İZMİR/KONAK": {0, 0, 0},
+			},
+			farklar:    map[string]int{},
+			textIcerir: []string{"4 sandik degismedi"},
+		},
+		{
+			name: "tekrar", eski: "eski.csv", yeni: "eski.csv",
+			durumlar:   map[string]string{},
+			tekrar:     [2]int{1, 1},
+			degismeyen: 4,
+			ozetler: map[string][3]int{
+				"ANKARA/ÇANKAYA": {0, 0, 0}, "ANKARA/SİNCAN": {0, 0, 0}, "İZMİR/KONAK": {0, 0, 0},
+			},
+			farklar:    map[string]int{},
+			textIcerir: []string{"! tekrarlanan sandik: eski 1, yeni 1"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := diffSnapshots(readSnapshot(fixture(tc.eski)), readSnapshot(fixture(tc.yeni)))
+			durumlar := make(map[string]string)
+			for _, sf := range d.Sandiklar {
+				durumlar[sf.Anahtar] = sf.Durum
+			}
+			if !reflect.DeepEqual(durumlar, tc.durumlar) {
+				t.Errorf("boxes: got %v, want %v", durumlar, tc.durumlar)
+			}
+			if got := [2]int{d.TekrarlananEski, d.TekrarlananYeni}; got != tc.tekrar {
+				t.Errorf("duplicates: got %v, want %v", got, tc.tekrar)
+			}
+			if d.Degismeyen != tc.degismeyen {
+				t.Errorf("unchanged: got %d, want %d", d.Degismeyen, tc.degismeyen)
+			}
+			if !reflect.DeepEqual(d.EklenenSutunlar, tc.eklenenSut) || len(d.SilinenSutunlar) != 0 {
+				t.Errorf("columns: +%v -%v", d.EklenenSutunlar, d.SilinenSutunlar)
+			}
+			ozetler := make(map[string][3]int)
+			farklar := map[string]int{}
+			for _, o := range d.Ozetler {
+				ozetler[o.Il+"/"+o.Ilce] = [3]int{o.Eklenen, o.Silinen, o.Degisen}
+				if o.Il+"/"+o.Ilce == "ANKARA/ÇANKAYA" {
+					for col, v := range o.Farklar {
+						farklar[col] = v
+					}
+				}
+			}
+			if !reflect.DeepEqual(ozetler, tc.ozetler) {
+				t.Errorf("summaries: got %v, want %v", ozetler, tc.ozetler)
+			}
+			if !reflect.DeepEqual(farklar, tc.farklar) {
+				t.Errorf("ANKARA/ÇANKAYA deltas: got %v, want %v", farklar, tc.farklar)
+			}
+			var buf bytes.Buffer
+			d.fprintText(&buf)
+			for _, want := range tc.textIcerir {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("text output lacks %q:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
+
+// json ciktisinin alan adlari disaridan okunuyor; degismemeli
+func TestDiffJSON(t *testing.T) {
+	d := diffSnapshots(readSnapshot(filepath.Join("testdata", "diff", "eski.csv")),
+		readSnapshot(filepath.Join("testdata", "diff", "yeni.csv")))
+	b, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	anahtarlar := func(v any) string {
+		var l []string
+		for k := range v.(map[string]any) {
+			l = append(l, k)
+		}
+		sort.Strings(l)
+		return strings.Join(l, ",")
+	}
+	sandiklar := got["sandiklar"].([]any)
+	degisen := sandiklar[0].(map[string]any)
+	for _, tc := range []struct{ ad, got, want string }{
+		{"diff", anahtarlar(got), "degismeyen,eklenenSutunlar,eski,ozetler,sandiklar,tekrarlananEski,tekrarlananYeni,yeni"},
+		{"sandik", anahtarlar(degisen), "anahtar,durum,farklar,il,ilce"},
+		{"eklenen sandik", anahtarlar(sandiklar[1]), "anahtar,durum,il,ilce"},
+		{"fark", anahtarlar(degisen["farklar"].([]any)[0]), "eski,fark,sutun,yeni"},
+		{"ozet", anahtarlar(got["ozetler"].([]any)[0]), "degisen,eklenen,farklar,il,ilce,silinen"},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s fields: got %s, want %s", tc.ad, tc.got, tc.want)
+		}
+	}
+	if fark := degisen["farklar"].([]any)[1].(map[string]any); fark["sutun"] != "B PARTİSİ" || fark["fark"] != -5.0 {
+		t.Errorf("unexpected delta %v", fark)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/secim/src/client"
+	"github.com/secim/src/logx"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// http yanit onbellegi; -out-root altinda
+const httpCacheDir = "cache/http/"
+
+// parseFlags -config bayragini ekleyip argumanlari okur. Config dosyasi
+// bayrak adi -> deger eslemesi iceren bir JSON nesnesidir; komut satirinda
+// verilen bayraklar dosyadakileri ezer. Ornek:
+//
+//	{"proxy": "socks5://127.0.0.1:9050", "timeout": "30s", "header": ["X-A: 1"]}
+//
+// Loglama bayraklari (-log-level, -log-format, -quiet, -log-file) de burada
+// eklenir; varsayilan logger config uygulandiktan sonra kurulur.
+func parseFlags(fs *flag.FlagSet, args []string) {
+	config := fs.String("config", "", "bayrak degerlerini iceren JSON config dosyasi")
+	setupLog := logFlags(fs)
+	_ = fs.Parse(args)
+	defer setupLog()
+	if *config == "" {
+		return
+	}
+	b, err := os.ReadFile(*config)
+	if err != nil {
+		logx.Fatal("cannot read config file", logx.Err(err))
+	}
+	var m map[string]any
+	if err = json.Unmarshal(b, &m); err != nil {
+		logx.Fatal("cannot parse config file", logx.F("dosya", *config), logx.Err(err))
+	}
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for name, v := range m {
+		if set[name] {
+			continue
+		}
+		if fs.Lookup(name) == nil {
+			logx.Fatal("config: komutta boyle bir bayrak yok", logx.F("komut", fs.Name()), logx.F("bayrak", name))
+		}
+		// listeler tekrarlanabilir bayraklar icin (ornek: header)
+		vals, ok := v.([]any)
+		if !ok {
+			vals = []any{v}
+		}
+		for _, val := range vals {
+			if err = fs.Set(name, fmt.Sprint(val)); err != nil {
+				logx.Fatal("config: gecersiz deger", logx.F("bayrak", name), logx.Err(err))
+			}
+		}
+	}
+}
+
+// logFlags loglama bayraklarini ekler; donen fonksiyon varsayilan logger'i kurar
+func logFlags(fs *flag.FlagSet) func() {
+	level := fs.String("log-level", "info", "log seviyesi: debug|info|warn|error")
+	format := fs.String("log-format", "text", "log bicimi: text|logfmt|json")
+	quiet := fs.Bool("quiet", false, "sadece uyari ve hatalari yaz (-log-level warn)")
+	file := fs.String("log-file", "", "loglari stderr yerine bu dosyaya ekle")
+	return func() {
+		lvl, err := logx.ParseLevel(*level)
+		if err != nil {
+			logx.Fatal("gecersiz log seviyesi", logx.Err(err))
+		}
+		if *quiet && lvl < logx.LevelWarn {
+			lvl = logx.LevelWarn
+		}
+		f, err := logx.ParseFormat(*format)
+		if err != nil {
+			logx.Fatal("gecersiz log bicimi", logx.Err(err))
+		}
+		var w io.Writer = os.Stderr
+		if *file != "" {
+			// surec boyunca acik kalir
+			lf, err := os.OpenFile(*file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, filePerm)
+			if err != nil {
+				logx.Fatal("cannot open log file", logx.F("dosya", *file), logx.Err(err))
+			}
+			w = lf
+		}
+		logx.SetDefault(logx.New(w, lvl, f))
+	}
+}
+
+// headerFlag "Ad: deger" bicimindeki tekrarlanabilir -header bayragi
+type headerFlag http.Header
+
+func (h headerFlag) String() string {
+	l := make([]string, 0, len(h))
+	for k, v := range h {
+		l = append(l, k+": "+strings.Join(v, ","))
+	}
+	return strings.Join(l, "; ")
+}
+
+func (h headerFlag) Set(s string) error {
+	k, v, ok := strings.Cut(s, ":")
+	if !ok || strings.TrimSpace(k) == "" {
+		return fmt.Errorf("header 'Ad: deger' biciminde olmali: %q", s)
+	}
+	http.Header(h).Add(strings.TrimSpace(k), strings.TrimSpace(v))
+	return nil
+}
+
+// clientFlags komutlarin ortak http bayraklarini ekler;
+// donen fonksiyon Parse'tan ve outputFlags'in kurulumundan sonra istemciyi
+// kurar; http onbellegi -out-root altindadir. Ayni url'e yapilan
+// istekler her zaman tekillestirilir; kazanc kosu.tekillestirme() ile raporlanabilir.
+func clientFlags(fs *flag.FlagSet) func() client.Client {
+	def := client.DefaultOptions()
+	httpCache := fs.Bool("http-cache", false, "http yanitlarini <out-root>/"+httpCacheDir+" altinda onbellekle")
+	caFile := fs.String("ca-file", "", "sistem havuzuna ek guvenilecek PEM CA paketi")
+	pins := fs.String("pin", "", "virgulle ayrilmis sunucu SPKI / sertifika sha256 pin'leri")
+	insecure := fs.Bool("insecure", false, "TLS sertifika dogrulamasini kapat (guvensiz!)")
+	proxy := fs.String("proxy", "", "http(s):// veya socks5:// proxy (bos = HTTP_PROXY ortam degiskenleri)")
+	timeout := fs.Duration("timeout", def.Timeout, "istek basina toplam zaman asimi")
+	headerTimeout := fs.Duration("header-timeout", def.HeaderTimeout, "yanit basliklari icin zaman asimi")
+	maxConns := fs.Int("max-conns-per-host", def.MaxConnsPerHost, "host basina en fazla baglanti (0 = sinirsiz)")
+	maxIdle := fs.Int("max-idle-conns", def.MaxIdleConns, "havuzda tutulacak bos baglanti sayisi")
+	userAgent := fs.String("user-agent", def.UserAgent, "User-Agent basligi")
+	headers := headerFlag{}
+	fs.Var(headers, "header", "her istege eklenecek 'Ad: deger' basligi (tekrarlanabilir)")
+	http2 := fs.Bool("http2", def.HTTP2, "HTTP/2 dene")
+	apiURL := fs.String("api-url", "", "istekleri kokpit yerine bu adrese gonder (ayna veya test sunucusu)")
+	setupSema := semaFlags(fs)
+	return func() client.Client {
+		o := client.Options{
+			TLS:   client.TLSOptions{CAFile: *caFile, Insecure: *insecure},
+			Proxy: *proxy, Timeout: *timeout, HeaderTimeout: *headerTimeout,
+			MaxConnsPerHost: *maxConns, MaxIdleConns: *maxIdle,
+			UserAgent: *userAgent, Headers: http.Header(headers), HTTP2: *http2,
+		}
+		if *pins != "" {
+			o.TLS.Pins = strings.Split(*pins, ",")
+		}
+		if *insecure {
+			logx.Warn("TLS sertifika dogrulamasi kapali; veri yolda degistirilebilir")
+		}
+		d, err := client.NewHTTPClient(o)
+		if err != nil {
+			logx.Fatal("cannot create http client", logx.Err(err))
+		}
+		// onbellek isabetleri ag istegi sayilmasin diye onbellegin altinda
+		d = client.Instrument(d)
+		if *httpCache {
+			d = client.NewDiskCache(filepath.Join(cikti.kok, httpCacheDir), d, client.DefaultCacheRules)
+		}
+		// onbellek ayna yanitlarini kokpit adresiyle anahtarlamasin diye
+		// yonlendirme onbellegin ustunde
+		if *apiURL != "" {
+			if d, err = client.Redirect(d, *apiURL); err != nil {
+				logx.Fatal("gecersiz api adresi", logx.F("api-url", *apiURL), logx.Err(err))
+			}
+		}
+		dc := client.Dedup(client.From(d), client.DefaultMemoEndpoints)
+		kosu.setClient(dc)
+		return setupSema(dc)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/secim/src"
+	"github.com/secim/src/geo"
+	"github.com/secim/src/logx"
+)
+
+// exportGeoKomutu il, secim cevresi, ilce, muhtarlik, ulke, dis temsilcilik
+// ve gumruk kapisi listelerini gezip id'leri, sandik araliklari ve ust
+// baglantilariyla normalize bir referans veri seti yazar.
+func exportGeoKomutu(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("export-geo", flag.ExitOnError)
+	newClient := clientFlags(fs)
+	isCB := fs.Bool("cb", false, "cumhurbaskanligi listeleri (varsayilan mv)")
+	muhtarlik := fs.Bool("muhtarlik", true, "muhtarliklari da gez (ilce basina bir istek)")
+	format := fs.String("format", "csv", "cikti formati: csv veya json")
+	out := fs.String("o", "", "cikti dosyasi (bos = -name-template ile output/ altinda, ornek geo<MV|CB>-<zaman>.<format>)")
+	setupOutput := outputFlags(fs)
+	parseFlags(fs, args)
+	setupOutput()
+	if *format != "csv" && *format != "json" {
+		logx.Fatal("gecersiz format", logx.F("format", *format))
+	}
+	c := newClient()
+	st := secimTurID(*isCB)
+
+	veri := geo.New()
+	cevreler := src.IlListesi(ctx, c, st, src.SandikTuruIlce)
+	for cevIdx, cev := range cevreler {
+		logx.Info("Birimler cekiliyor", logx.F("isCB", *isCB), logx.F("il", cev.IlADI),
+			logx.F("ilerleme", ilerleme(cevIdx, len(cevreler))))
+		veri.AddCevre(cev)
+		for _, ic := range src.IlceListesi(ctx, c, cev, st, src.SandikTuruIlce) {
+			veri.AddIlce(ic)
+			if !*muhtarlik {
+				continue
+			}
+			for _, m := range src.MuhtarlikListesi(ctx, c, ic, st, src.SandikTuruIlce) {
+				veri.AddMuhtarlik(ic, m)
+			}
+		}
+	}
+	logx.Info("Yurt disi birimleri cekiliyor")
+	for _, u := range src.UlkeListesi(ctx, c) {
+		veri.AddUlke(u)
+		for _, dt := range src.DisTemsilcilikListesi(ctx, c, u) {
+			veri.AddDisTemsilcilik(dt)
+		}
+	}
+	for _, g := range src.GumrukListesi(ctx, c) {
+		veri.AddGumruk(g)
+	}
+
+	w, fn, closeOut := openCikti(*out, "geo", *isCB, *format)
+	var err error
+	if *format == "json" {
+		err = veri.WriteJSON(w)
+	} else {
+		err = veri.WriteCSV(w)
+	}
+	if err != nil {
+		logx.Fatal("cannot write to file", logx.F("dosya", fn), logx.Err(err))
+	}
+	closeOut()
+	logx.Info("Cografi referans verisi yazildi", logx.F("sayilar", veri.Sayilar()), logx.F("dosya", fn))
+}
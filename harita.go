@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/secim/src"
+	"github.com/secim/src/harita"
+	"github.com/secim/src/logx"
+	"os"
+)
+
+// geojsonKomutu yurt ici ve cezaevi sandik sonuclarini il veya ilce bazinda toplayip
+// kullanicinin verdigi sinir dosyasindaki il / ilcelerle esler; oy paylari,
+// katilim ve kazanan ozellikleriyle GeoJSON yazar:
+//
+//	geojson -sinir iller.geojson -ad-prop name
+//	geojson -sinir ilceler.geojson -seviye ilce -ad-prop NAME_2 -il-ad-prop NAME_1
+func geojsonKomutu(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("geojson", flag.ExitOnError)
+	newClient := clientFlags(fs)
+	isCB := fs.Bool("cb", false, "cumhurbaskanligi sonuclari (varsayilan mv)")
+	sinir := fs.String("sinir", "", "il / ilce sinirlarinin GeoJSON dosyasi (zorunlu)")
+	seviye := fs.String("seviye", "il", "sinir seviyesi: il veya ilce")
+	idProp := fs.String("id-prop", "", "sinirlarda kokpit il / ilce id'sini tutan ozellik (bos = ada gore esle)")
+	adProp := fs.String("ad-prop", "name", "sinirlarda il / ilce adini tutan ozellik")
+	ilAdProp := fs.String("il-ad-prop", "", "ilce sinirlarinda il adini tutan ozellik; ayni adli ilceleri ayirir")
+	out := fs.String("o", "", "cikti dosyasi (bos = -name-template ile output/ altinda, - = stdout)")
+	setupOutput := outputFlags(fs)
+	parseFlags(fs, args)
+	setupOutput()
+	ayar := harita.Ayar{Seviye: harita.Seviye(*seviye), IDAlani: *idProp, AdAlani: *adProp, IlAdAlani: *ilAdProp}
+	if ayar.Seviye != harita.IlSeviyesi && ayar.Seviye != harita.IlceSeviyesi {
+		logx.Fatal("gecersiz seviye", logx.F("seviye", *seviye))
+	}
+	if *sinir == "" {
+		logx.Fatal("sinir dosyasi verilmeli: -sinir")
+	}
+	// sinir dosyasi butun sonuclar cekilmeden once okunur
+	f, err := os.Open(*sinir)
+	if err != nil {
+		logx.Fatal("cannot open file", logx.F("dosya", *sinir), logx.Err(err))
+	}
+	fc, err := harita.Oku(f)
+	_ = f.Close()
+	if err != nil {
+		logx.Fatal("cannot read boundaries", logx.F("dosya", *sinir), logx.Err(err))
+	}
+	c := newClient()
+	st := secimTurID(*isCB)
+
+	top := harita.New()
+	// cezaevi sandiklari ilcelerinin toplamina eklenir; ilce listesinde de
+	// gelen cezaevi sandigi tekil kimligiyle bir kez sayilir
+	sayildi := make(map[string]bool)
+	for _, k := range []ilKapsami{yurticiKapsami, cezaeviKapsami} {
+		cevreler := k.Birimler(ctx, c, st)
+		for cevIdx, cev := range cevreler {
+			logx.Info(k.bilgi.Etiket+" sandik sonuclari toplaniyor", logx.F("isCB", *isCB), logx.F("il", cev.IlADI),
+				logx.F("ilerleme", ilerleme(cevIdx, len(cevreler))))
+			sutunlar := baslikKaydet(st, cev.SecimCEVRESIID, src.SecimSonucBaslikListesi(ctx, c, cev, st)).
+				Sutunlar(cev.SecimCEVRESIID)
+			for _, ic := range src.IlceListesi(ctx, c, cev, st, k.sandikTuru) {
+				q := k.params(ic, st)
+				for j, s := range src.SandikSonuclari(ctx, c, q) {
+					tekil := q.Kimlik(map[string]any{src.KeySandikID: s.SandikID, src.KeySandikNO: s.SandikNO,
+						src.KeySandikRUMUZ: s.SandikRUMUZ}, j).Tekil()
+					if sayildi[tekil] {
+						continue
+					}
+					sayildi[tekil] = true
+					top.Ekle(ic, s, sutunlar)
+				}
+			}
+		}
+	}
+
+	rapor := harita.Birlestir(fc, top, ayar)
+	for _, e := range rapor.Eslesmeyen {
+		logx.Warn("Sinir eslesmedi", logx.F("sinir", e))
+	}
+	for _, tp := range rapor.Kullanilmayan {
+		logx.Warn("Sonuc hicbir sinira eslenmedi", logx.F("il", tp.IlAdi), logx.F("ilce", tp.IlceAdi),
+			logx.F("ilId", tp.IlID), logx.F("ilceId", tp.IlceID))
+	}
+
+	w, fn, closeOut := openCikti(*out, "harita", *isCB, "geojson")
+	if err := fc.Yaz(w); err != nil {
+		logx.Fatal("cannot write to file", logx.F("dosya", fn), logx.Err(err))
+	}
+	closeOut()
+	logx.Info("GeoJSON yazildi", logx.F("eslesen", rapor.Eslesen), logx.F("eslesmeyen", len(rapor.Eslesmeyen)),
+		logx.F("kullanilmayan", len(rapor.Kullanilmayan)), logx.F("dosya", fn))
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"github.com/secim/src"
+	"github.com/secim/src/logx"
+	"github.com/secim/src/metrics"
+	"sync"
+)
+
+// anahtarSutunu csv ciktilarinin ilk sutunu; src.SandikKimligi.String()
+const anahtarSutunu = "anahtar"
+
+var duplicateBoxes = metrics.NewCounter("kokpit_duplicate_boxes_total",
+	"Birden fazla sorguda gelen sandiklar.", "scope")
+
+// sandikTekrari calismada ikinci kez gelen bir sandik
+type sandikTekrari struct {
+	Anahtar    string `json:"anahtar"`
+	Kapsam     string `json:"kapsam"`
+	IlkAnahtar string `json:"ilkAnahtar"`
+	IlkKapsam  string `json:"ilkKapsam"`
+}
+
+// sandikDefteri calisma boyunca yazilan sandiklari tekil kimlikleriyle
+// tutar; ortusen sorgular (ornek: cezaevi sandiklarinin ilce listesinde de
+// gelmesi) ayni sandigi iki kez yazarsa tekrari raporlar
+type sandikDefteri struct {
+	mu      sync.Mutex
+	gorulen map[string]sandikTekrari
+}
+
+var defter = sandikDefteri{gorulen: make(map[string]sandikTekrari)}
+
+// kaydet sandigi deftere yazar; daha once gorulmusse tekrari loglar, sayar
+// ve manifest'e ekler. Kapsamlar paralel calistigi icin hangisinin once
+// geldigi belli degil; satirlar atilmaz, iki dosyada da kalir.
+func (d *sandikDefteri) kaydet(k src.SandikKimligi, scope string) bool {
+	anahtar := k.String()
+	d.mu.Lock()
+	ilk, ok := d.gorulen[k.Tekil()]
+	if !ok {
+		d.gorulen[k.Tekil()] = sandikTekrari{IlkAnahtar: anahtar, IlkKapsam: scope}
+	}
+	d.mu.Unlock()
+	if !ok {
+		return false
+	}
+	t := sandikTekrari{Anahtar: anahtar, Kapsam: scope, IlkAnahtar: ilk.IlkAnahtar, IlkKapsam: ilk.IlkKapsam}
+	duplicateBoxes.Inc(scope)
+	logx.Warn("Sandik birden fazla sorguda geldi", logx.F("anahtar", t.Anahtar), logx.F("kapsam", t.Kapsam),
+		logx.F("ilkAnahtar", t.IlkAnahtar), logx.F("ilkKapsam", t.IlkKapsam))
+	kosu.tekrarEkle(t)
+	return true
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"github.com/secim/src"
-	"github.com/secim/src/client"
+	"github.com/secim/src/logx"
+	"github.com/secim/src/progress"
+	"github.com/secim/src/registry"
 	"io"
-	"log"
 	"os"
+	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
@@ -16,30 +20,115 @@ import (
 )
 
 func main() {
-	c := client.From(client.NewHTTPClient())
+	// ilk arguman bayrak degilse komut adidir; varsayilan komut sandik cekimi
+	cmd, args := "sandik", os.Args[1:]
+	if len(args) != 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+	ctx := signalContext()
+	kosu.setKomut(cmd)
+	logx.SetExitFunc(fatalCikis)
+	func() {
+		// iptalde komut Canceled panic'iyle biter; defer'ler dosyalari kapatmis olur
+		defer src.Recover()
+		switch cmd {
+		case "sandik":
+			sandikKomutu(ctx, args)
+		case "mv-agac":
+			mvAgacKomutu(ctx, args)
+		case "watch":
+			izleKomutu(ctx, args)
+		case "diff":
+			diffKomutu(args)
+		case "registry":
+			registryKomutu(ctx, args)
+		case "cache":
+			cacheKomutu(args)
+		case "tutanak":
+			tutanakKomutu(ctx, args)
+		case "export-geo":
+			exportGeoKomutu(ctx, args)
+		case "geojson":
+			geojsonKomutu(ctx, args)
+		default:
+			logx.Fatal("bilinmeyen komut (sandik, mv-agac, watch, diff, registry, cache, tutanak, export-geo, geojson)", logx.F("komut", cmd))
+		}
+	}()
+	kapanis(ctx)
+}
+
+// sandikKomutu tum kapsamlar icin cb ve mv sandik sonuclarini ceker.
+// -o - ile tek kapsamin tek secim turunu temp/ ve output/'a yazmadan
+// stdout'a yazar; kapsamlarin sutunlari farkli oldugu icin tek akis tek kapsamdir:
+//
+//	sandik -o - -kapsam disTemsSandiklar -cb -compress zstd > yurtdisi.csv.zst
+func sandikKomutu(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("sandik", flag.ExitOnError)
+	newClient := clientFlags(fs)
+	serveMetrics := metricsFlags(fs)
+	startProgress := progressFlags(fs)
+	out := fs.String("o", "", "- = -kapsam ve -cb ile secilen tek kapsami stdout'a yaz (bos = tum kapsamlar output/ altina)")
+	kapsamAdi := fs.String("kapsam", "sandiklar",
+		"-o - ile yazilacak kapsam: sandiklar|cezaeviSandiklar|disTemsSandiklar|gumrukSandiklar")
+	isCB := fs.Bool("cb", false, "-o - ile cumhurbaskanligi sandiklari (varsayilan mv)")
+	setupOutput := outputFlags(fs)
+	parseFlags(fs, args)
+	setupOutput()
+	kapsamlar, turler := sandikKapsamlari, []bool{false, true}
+	panoCikti := os.Stdout
+	switch *out {
+	case "":
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name == "kapsam" || f.Name == "cb" {
+				logx.Fatal("-kapsam ve -cb sadece -o - ile verilebilir", logx.F("bayrak", f.Name))
+			}
+		})
+	case "-":
+		if cikti.ilBazli {
+			logx.Fatal("-o - tek akis yazar; -split-il ile kullanilamaz")
+		}
+		kapsamlar = nil
+		for _, k := range sandikKapsamlari {
+			if k.ad == *kapsamAdi {
+				kapsamlar = append(kapsamlar, k)
+			}
+		}
+		if len(kapsamlar) == 0 {
+			logx.Fatal("bilinmeyen kapsam", logx.F("kapsam", *kapsamAdi))
+		}
+		turler = []bool{*isCB}
+		cikti.stdout = true
+		// pano veriyle karismasin
+		panoCikti = os.Stderr
+	default:
+		logx.Fatal("-o sadece - olabilir; dosya adlari -name-template ile verilir", logx.F("o", *out))
+	}
+	serveMetrics()
+	c := newClient()
+	pano, stopProgress := startProgress(panoCikti)
 	wg := sync.WaitGroup{}
-	// klasorleri olustur
-	if err := os.MkdirAll("cache/", 0o777); err != nil {
-		log.Fatalf("Onbellek dizini olusturulamiyor! (cache/)")
-	} else if err = os.MkdirAll("output/", 0o777); err != nil {
-		log.Fatalf("Cikti dizini olusturulamiyor! (output/)")
-	} else if err = os.MkdirAll("temp/", 0o777); err != nil {
-		log.Fatalf("Temp dizini olusturulamiyor! (temp/)")
+	// klasorleri olustur; temp/ ve output/ setupOutput'ta olusturulur
+	if err := os.MkdirAll("cache/", dirPerm); err != nil {
+		logx.Fatal("Onbellek dizini olusturulamiyor!", logx.F("dizin", "cache/"), logx.Err(err))
 	}
 	// cb ve mv icin ic / dis fetch paralel baslat
-	for _, isCB := range []bool{false, true} {
-		wg.Add(1)
-		go icSandik(c, &wg, isCB)
-		wg.Add(1)
-		go disTemsSandik(c, &wg, isCB)
-		wg.Add(1)
-		go cezaeviSandik(c, &wg, isCB)
-		wg.Add(1)
-		go gumrukSandik(c, &wg, isCB)
+	for _, isCB := range turler {
+		for _, k := range kapsamlar {
+			wg.Add(1)
+			go k.cek(ctx, c, &wg, isCB, panoTask(pano, k.ad, isCB))
+		}
 	}
 	// tum goroutine'leri bekle
 	wg.Wait()
-	fmt.Println("DONE.")
+	stopProgress()
+	st := kosu.tekillestirme()
+	logx.Info("Istek tekillestirme", logx.F("istatistik", st), logx.F("kazanilan", st.Saved()))
+	metrikOzeti()
+	if ctx.Err() != nil {
+		logx.Warn("Iptal edildi; yarim dosyalar temp/ dizininde birakildi")
+		return
+	}
+	logx.Info("DONE.")
 }
 
 // region utils
@@ -47,7 +136,7 @@ func main() {
 // hata alirsak dogrudan programi kapatalim diye tembellik util'i
 func must(_ int, err error) {
 	if err != nil {
-		log.Fatalf("cannot write to file: %v", err)
+		logx.Fatal("cannot write to file", logx.Err(err))
 	}
 }
 
@@ -59,37 +148,67 @@ func secimTurID(isCB bool) int {
 	return 8
 }
 
+// dosya adlarinda ve metriklerde secim turu on eki
+func cbPrefix(isCB bool) string {
+	if isCB {
+		return "CB"
+	}
+	return "MV"
+}
+
 // timestamp'ler icin sabit konum: Europe/Istanbul (UTC+3)
 // makinenin saati bozuk oldugu icin bunu enforce etmek gerekli
 var loc = time.FixedZone("UTC+3", 3*60*60)
 
-// ilgili csv dosyasini olustur, defer edilecek fonksiyonla beraber don
-func openFile(title string, isCB bool) (io.Writer, func()) {
-	prefix := "MV"
-	if isCB {
-		prefix = "CB"
+// ilgili csv dosyasini olustur, defer edilecek fonksiyonla beraber don.
+// ctx iptal edildiyse dosya yarim kabul edilir ve temp/'te birakilir.
+func openFile(ctx context.Context, title string, isCB bool) (io.Writer, func()) {
+	return openIlFile(ctx, title, isCB, "")
+}
+
+// openIlFile openFile'in il bazli hali; il adi sablondaki {il} alanina yazilir
+func openIlFile(ctx context.Context, title string, isCB bool, il string) (io.Writer, func()) {
+	if cikti.stdout {
+		// stdout'a yazilan akis manifest'e girmez
+		w := newOzetYazici(os.Stdout, cikti.sikistirmaOf(""), "csv")
+		return w, func() {
+			if err := w.Close(); err != nil {
+				logx.Error("cannot write to stdout", logx.F("kapsam", title+cbPrefix(isCB)), logx.Err(err))
+			}
+		}
 	}
 	// ornek: temp/sandiklarCB-14-05-2023-23-04.csv
-	tm := time.Now().In(loc).Format("02-01-2006-15-04")
-	fn := fmt.Sprintf("temp/%s%s-%s.csv", title, prefix, tm)
-	lastFn := fmt.Sprintf("output/%s%s-%s.csv", title, prefix, tm)
-	w, err := os.Create(fn)
+	ad := cikti.ad(title, isCB, il, "csv", time.Now())
+	f, fn, err := createFile(filepath.Join(cikti.tempDir(), ad))
 	if err != nil {
-		log.Fatalf("cannot open file: %v\n", err)
+		logx.Fatal("cannot open file", logx.F("dosya", fn), logx.Err(err))
 	}
+	// manifest icin sha256 ve satir sayisi yazarken hesaplanir
+	w := newOzetYazici(f, cikti.sikistirmaOf(ad), "csv")
 	// dosya adini yazdir
-	fmt.Printf("Dosya olusturuluyor: %s\n", fn)
+	logx.Info("Dosya olusturuluyor", logx.F("dosya", fn))
 	return w, func() {
 		// close fonksiyonu
-		if er := w.Close(); er != nil {
-			log.Printf("cannot close file: %v\n", err)
-		} else if er = os.Rename(fn, lastFn); er != nil {
-			log.Printf("cannot move file to %s: %v\n", lastFn, err)
+		scope := title + cbPrefix(isCB)
+		er := w.Close()
+		if e := f.Close(); er == nil {
+			er = e
+		}
+		if er != nil {
+			logx.Error("cannot close file", logx.F("dosya", fn), logx.Err(er))
+		} else if ctx.Err() != nil {
+			logx.Warn("Yarim dosya temp'te birakildi", logx.F("dosya", fn))
+			kosu.ciktiEkle(w.kayit(fn, scope, isCB, false))
+		} else if lastFn, er := cikti.tasi(fn, ad); er != nil {
+			logx.Error("cannot move file", logx.F("dosya", lastFn), logx.Err(er))
+		} else {
+			kosu.ciktiEkle(w.kayit(lastFn, scope, isCB, true))
 		}
 	}
 }
 
-// csv icin string type'lari quote icine alma
+// csv icin string type'lari quote icine alma. Degerler ham api satirlarindan
+// (json sayilari float64) gelir; neden tipli src.SandikSonuc olmadigi orada.
 func quoteVal(a any) (s string) {
 	switch v := a.(type) {
 	case bool:
@@ -124,43 +243,21 @@ func turOrd(n string) int {
 	return 0
 }
 
-func colNameBaslikMap(basliklar []src.SecimSonucBaslik, uniq bool) map[string]src.SecimSonucBaslik {
-	m := make(map[string]src.SecimSonucBaslik)
-	for _, baslik := range basliklar {
-		if baslik.SiraNO == 0 {
-			// backendde bazi adaylar kasten boyle skip edilmis
-			continue
-		}
-		if uniq {
-			if b, ok := m[baslik.ColumnNAME]; ok && (b.Ad != baslik.Ad) {
-				log.Fatalf("sutun adi onceden girilmis! %s (%s, %s)",
-					baslik.ColumnNAME, b.Ad, baslik.Ad)
-			}
-		}
-		m[baslik.ColumnNAME] = baslik
-	}
-	return m
-}
-
-func adBaslikMap(basliklar []src.SecimSonucBaslik, uniq bool) map[string]src.SecimSonucBaslik {
-	m := make(map[string]src.SecimSonucBaslik)
-	for _, baslik := range basliklar {
-		if baslik.SiraNO == 0 {
-			// backendde bazi adaylar kasten boyle skip edilmis
-			continue
-		}
-		if uniq {
-			if b, ok := m[baslik.Ad]; ok && (b.ColumnNAME != baslik.ColumnNAME) {
-				log.Fatalf("ad onceden girilmis! %s (%s, %s)",
-					baslik.Ad, b.ColumnNAME, baslik.ColumnNAME)
-			}
+// baslikKaydet bir cevrenin basliklarini secim turunun calisma kaydina ekler.
+// Cakisan sutun adlari / adlar programi durdurmaz: sutunda ilk, adda son baslik kullanilir,
+// catisma loglanir ve manifest'e yazilir. Sutun haritalari kayittan alinir.
+func baslikKaydet(st, cevreID int, basliklar []src.SecimSonucBaslik) *registry.Registry {
+	reg := kosu.baslikKaydi(st)
+	if l := reg.Add(cevreID, basliklar); len(l) > 0 {
+		for _, cf := range l {
+			logx.Warn("baslik catismasi", logx.F("catisma", cf))
 		}
-		m[baslik.Ad] = baslik
+		kosu.catismaEkle(l)
 	}
-	return m
+	return reg
 }
 
-// adBaslikMap veya colNameBaslikMap ciktisi alabilir
+// toOrdSutunlar registry.Adlar veya registry.Sutunlar ciktisi alabilir
 func toOrdSutunlar(m map[string]src.SecimSonucBaslik) []src.SecimSonucBaslik {
 	// degerleri listeye diz
 	sutunlar := make([]src.SecimSonucBaslik, 0, len(m))
@@ -186,6 +283,24 @@ func toOrdSutunlar(m map[string]src.SecimSonucBaslik) []src.SecimSonucBaslik {
 
 var units = []string{"B", "kB", "MB", "GB"}
 
+// ilerleme dongu indeksini "3/81" bicimine cevirir
+func ilerleme(idx, n int) string {
+	return fmt.Sprintf("%d/%d", idx+1, n)
+}
+
+// scopeLogger kapsam goroutine'lerinin ortak alanlariyla logger doner
+func scopeLogger(scope string, isCB bool) *logx.Logger {
+	return logx.With(logx.F("scope", scope), logx.F("isCB", isCB))
+}
+
+// panoTask kapsam icin pano satiri ekler; pano kapaliysa nil doner
+func panoTask(pano *progress.Board, scope string, isCB bool) *progress.Task {
+	if pano == nil {
+		return nil
+	}
+	return pano.Task(scope + cbPrefix(isCB))
+}
+
 func memUsage() string {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
@@ -203,9 +318,9 @@ type SutunBilgi struct {
 }
 
 type PrintCtx struct {
+	scope          string
 	ordCols        []src.SecimSonucBaslik
 	skippedColumns map[int]bool
-	i              int
 }
 
 func (sb *SutunBilgi) addRow(colNames map[string]src.SecimSonucBaslik, sonuc map[string]any) map[string]any {
@@ -226,9 +341,10 @@ func (sb *SutunBilgi) addRow(colNames map[string]src.SecimSonucBaslik, sonuc map
 	return m
 }
 
-func (sb *SutunBilgi) FprintHeader(w io.Writer, isSkipColumn func(src.SecimSonucBaslik) bool) *PrintCtx {
-	must(fmt.Fprint(w, "#"))
-	pc := &PrintCtx{ordCols: toOrdSutunlar(sb.Names), skippedColumns: make(map[int]bool)}
+// scope metriklerde satirlarin sayilacagi etikettir (ornek: sandiklarMV)
+func (sb *SutunBilgi) FprintHeader(w io.Writer, scope string, isSkipColumn func(src.SecimSonucBaslik) bool) *PrintCtx {
+	must(fmt.Fprint(w, anahtarSutunu))
+	pc := &PrintCtx{scope: scope, ordCols: toOrdSutunlar(sb.Names), skippedColumns: make(map[int]bool)}
 	for i, sutun := range pc.ordCols {
 		if isSkipColumn != nil && isSkipColumn(sutun) {
 			pc.skippedColumns[i] = true
@@ -240,9 +356,10 @@ func (sb *SutunBilgi) FprintHeader(w io.Writer, isSkipColumn func(src.SecimSonuc
 	return pc
 }
 
-func (pc *PrintCtx) FprintRow(w io.Writer, row map[string]any) {
-	pc.i++
-	must(fmt.Fprintf(w, "%d", pc.i))
+// FprintRow satiri sandik anahtariyla (src.SandikKimligi) baslayarak yazar
+func (pc *PrintCtx) FprintRow(w io.Writer, anahtar string, row map[string]any) {
+	rowsWritten.Inc(pc.scope)
+	must(fmt.Fprint(w, anahtar))
 	for j, sutun := range pc.ordCols {
 		if pc.skippedColumns[j] {
 			// skip this column
@@ -265,213 +382,6 @@ func skippedColumnsFn(isCB bool) func(src.SecimSonucBaslik) bool {
 	}
 }
 
-func disTemsSandik(c client.Client, wg *sync.WaitGroup, isCB bool) {
-	defer wg.Done()
-	st := secimTurID(isCB)
-
-	// basliklari cek
-	ulkeler := src.UlkeListesi(c)
-	fmt.Printf("Yurt disi sandik basliklari cekiliyor (cb=%v) [%s]\n", isCB, memUsage())
-	baslikList := src.YurtdisiSecimSonucBaslikListesi(c, st)
-	// tek scope; tum column name'ler unique olmali
-	colNames := colNameBaslikMap(baslikList, true)
-
-	var sb SutunBilgi
-	cacheFilename := fmt.Sprintf("cache/__disTemsSandiklar%d.cache", st)
-	if getSutunBilgiFromCache(cacheFilename, &sb) {
-		fmt.Printf("Yurt disi sandik sutun bilgileri onbellekten kullaniliyor (cb=%v) [%s]\n", isCB, memUsage())
-	} else {
-		// tek scope; tum adlar unique olmali
-		sb = SutunBilgi{Names: adBaslikMap(baslikList, true)}
-		fmt.Printf("Yurt disi sandik basliklari cekildi (cb=%v), %d sutun var [%s]\n",
-			isCB, len(sb.Names), memUsage())
-		for ulkeIdx, ulke := range ulkeler {
-			fmt.Printf("Yurt disi sandik verileri cekiliyor (cb=%v) (%d / %d ulke) %s [%s]\n",
-				isCB, ulkeIdx+1, len(ulkeler), ulke.UlkeADI, memUsage())
-			for _, dt := range src.DisTemsilcilikListesi(c, ulke) {
-				for _, sonuc := range src.SecimSandikSonucListesi(c, src.DisTemsSonucParams(dt, st)) {
-					// tum row'lari fetch et
-					sb.addRow(colNames, sonuc)
-				}
-			}
-		}
-		cacheSutunBilgi(cacheFilename, &sb)
-	}
-
-	// siralanmis basliklarla print
-	w, closeFile := openFile("disTemsSandiklar", isCB)
-	defer closeFile()
-	pc := sb.FprintHeader(w, skippedColumnsFn(isCB))
-	for ulkeIdx, ulke := range ulkeler {
-		fmt.Printf("Yurt disi sandik verileri yaziliyor (cb=%v) (%d / %d ulke) %s [%s]\n",
-			isCB, ulkeIdx+1, len(ulkeler), ulke.UlkeADI, memUsage())
-		for _, dt := range src.DisTemsilcilikListesi(c, ulke) {
-			for _, sonuc := range src.SecimSandikSonucListesi(c, src.DisTemsSonucParams(dt, st)) {
-				pc.FprintRow(w, sb.addRow(colNames, sonuc))
-			}
-		}
-	}
-	fmt.Printf("Yurt disi sandik verileri dosyaya yazildi (cb=%v) [%s].\n", isCB, memUsage())
-}
-
-func gumrukSandik(c client.Client, wg *sync.WaitGroup, isCB bool) {
-	defer wg.Done()
-	st := secimTurID(isCB)
-
-	// basliklari cek
-	gumrukler := src.GumrukListesi(c)
-	fmt.Printf("Gumruk sandik basliklari cekiliyor (cb=%v) [%s]\n", isCB, memUsage())
-	baslikList := src.YurtdisiSecimSonucBaslikListesi(c, st)
-	// tek scope; tum column name'ler unique olmali
-	colNames := colNameBaslikMap(baslikList, true)
-
-	var sb SutunBilgi
-	cacheFilename := fmt.Sprintf("cache/__gumrukSandiklar%d.cache", st)
-	if getSutunBilgiFromCache(cacheFilename, &sb) {
-		fmt.Printf("Gumruk sandik sutun bilgileri onbellekten kullaniliyor (cb=%v) [%s]\n", isCB, memUsage())
-	} else {
-		// tek scope; tum adlar unique olmali
-		sb = SutunBilgi{Names: adBaslikMap(baslikList, true)}
-		fmt.Printf("Gumruk sandik basliklari cekildi (cb=%v), %d sutun var [%s]\n",
-			isCB, len(sb.Names), memUsage())
-		for gumrukIdx, gumruk := range gumrukler {
-			fmt.Printf("Gumruk sandik verileri cekiliyor (cb=%v) (%d / %d gumruk) %s [%s]\n",
-				isCB, gumrukIdx+1, len(gumrukler), gumruk.GumrukADI, memUsage())
-			for _, sonuc := range src.SecimSandikSonucListesi(c, src.GumrukSonucParams(gumruk, st)) {
-				// tum row'lari fetch et
-				sb.addRow(colNames, sonuc)
-			}
-		}
-		cacheSutunBilgi(cacheFilename, &sb)
-	}
-
-	// siralanmis basliklarla print
-	w, closeFile := openFile("gumrukSandiklar", isCB)
-	defer closeFile()
-	pc := sb.FprintHeader(w, skippedColumnsFn(isCB))
-	for gumrukIdx, gumruk := range gumrukler {
-		fmt.Printf("Gumruk sandik verileri yaziliyor (cb=%v) (%d / %d gumruk) %s [%s]\n",
-			isCB, gumrukIdx+1, len(gumrukler), gumruk.GumrukADI, memUsage())
-		for _, sonuc := range src.SecimSandikSonucListesi(c, src.GumrukSonucParams(gumruk, st)) {
-			pc.FprintRow(w, sb.addRow(colNames, sonuc))
-		}
-	}
-	fmt.Printf("Gumruk sandik verileri dosyaya yazildi (cb=%v) [%s].\n", isCB, memUsage())
-}
-
-func icSandik(c client.Client, wg *sync.WaitGroup, isCB bool) {
-	defer wg.Done()
-	st := secimTurID(isCB)
-
-	fmt.Printf("Yurt ici sandik basliklari cekiliyor (cb=%v) [%s]\n", isCB, memUsage())
-	cevreler := src.IlListesi(c, st, 0)
-	cevBas := make([][]src.SecimSonucBaslik, 0, len(cevreler))
-	for _, cvr := range cevreler {
-		cevBas = append(cevBas, src.SecimSonucBaslikListesi(c, cvr, st))
-	}
-
-	var sb SutunBilgi
-	cacheFilename := fmt.Sprintf("cache/__yurticiSandiklar%d.cache", st)
-	if getSutunBilgiFromCache(cacheFilename, &sb) {
-		fmt.Printf("Yurt ici sandik sutun bilgileri onbellekten kullaniliyor (cb=%v) [%s]\n", isCB, memUsage())
-	} else {
-		// adBaslikMap tum basliklarin union'ını verir; uniq = false olmali
-		var basTmp []src.SecimSonucBaslik
-		for _, bas := range cevBas {
-			basTmp = append(basTmp, bas...)
-		}
-		sb = SutunBilgi{Names: adBaslikMap(basTmp, false)}
-		fmt.Printf("Yurt ici sandik basliklari cekildi (cb=%v), %d sutun var [%s]\n",
-			isCB, len(sb.Names), memUsage())
-
-		for cevIdx, cev := range cevreler {
-			fmt.Printf("Yurt ici sandik verileri cekiliyor (cb=%v) (%d / %d secim cevresi) %s [%s]\n",
-				isCB, cevIdx+1, len(cevreler), cev.IlADI, memUsage())
-			cevColNameBaslikMap := colNameBaslikMap(cevBas[cevIdx], true)
-			for _, ilce := range src.IlceListesi(c, cev, st, 0) {
-				for _, sonuc := range src.SecimSandikSonucListesi(c, src.IlceSonucParams(ilce, st)) {
-					// her cevrenin sonuclarini kendi column name'leriyle map'le
-					sb.addRow(cevColNameBaslikMap, sonuc)
-				}
-			}
-		}
-		cacheSutunBilgi(cacheFilename, &sb)
-	}
-
-	// siralanmis basliklarla print
-	w, closeFile := openFile("sandiklar", isCB)
-	defer closeFile()
-	pc := sb.FprintHeader(w, skippedColumnsFn(isCB))
-	for cevIdx, cev := range cevreler {
-		fmt.Printf("Yurt ici sandik verileri yaziliyor (cb=%v) (%d / %d secim cevresi) %s [%s]\n",
-			isCB, cevIdx+1, len(cevreler), cev.IlADI, memUsage())
-		cevColNameBaslikMap := colNameBaslikMap(cevBas[cevIdx], true)
-		for _, ilce := range src.IlceListesi(c, cev, st, 0) {
-			for _, sonuc := range src.SecimSandikSonucListesi(c, src.IlceSonucParams(ilce, st)) {
-				pc.FprintRow(w, sb.addRow(cevColNameBaslikMap, sonuc))
-			}
-		}
-	}
-	fmt.Printf("Yurt ici sandik verileri dosyaya yazildi (cb=%v).\n", isCB)
-}
-
-func cezaeviSandik(c client.Client, wg *sync.WaitGroup, isCB bool) {
-	const cezaeviSandikTuru = 2
-	defer wg.Done()
-	st := secimTurID(isCB)
-
-	fmt.Printf("Cezaevi sandik basliklari cekiliyor (cb=%v) [%s]\n", isCB, memUsage())
-	cevreler := src.IlListesi(c, st, cezaeviSandikTuru)
-	cevBas := make([][]src.SecimSonucBaslik, 0, len(cevreler))
-	for _, cvr := range cevreler {
-		cevBas = append(cevBas, src.SecimSonucBaslikListesi(c, cvr, st))
-	}
-
-	var sb SutunBilgi
-	cacheFilename := fmt.Sprintf("cache/__cezaeviSandiklar%d.cache", st)
-	if getSutunBilgiFromCache(cacheFilename, &sb) {
-		fmt.Printf("Cezaevi sandik sutun bilgileri onbellekten kullaniliyor (cb=%v) [%s]\n", isCB, memUsage())
-	} else {
-		// adBaslikMap tum basliklarin union'ını verir; uniq = false olmali
-		var basTmp []src.SecimSonucBaslik
-		for _, bas := range cevBas {
-			basTmp = append(basTmp, bas...)
-		}
-		sb = SutunBilgi{Names: adBaslikMap(basTmp, false)}
-		fmt.Printf("Cezaevi sandik basliklari cekildi (cb=%v), %d sutun var [%s]\n",
-			isCB, len(sb.Names), memUsage())
-
-		for cevIdx, cev := range cevreler {
-			fmt.Printf("Cezaevi sandik verileri cekiliyor (cb=%v) (%d / %d secim cevresi) %s [%s]\n",
-				isCB, cevIdx+1, len(cevreler), cev.IlADI, memUsage())
-			cevColNameBaslikMap := colNameBaslikMap(cevBas[cevIdx], true)
-			for _, ilce := range src.IlceListesi(c, cev, st, cezaeviSandikTuru) {
-				for _, sonuc := range src.SecimSandikSonucListesi(c, src.CezaeviSonucParams(ilce, st)) {
-					// her cevrenin sonuclarini kendi column name'leriyle map'le
-					sb.addRow(cevColNameBaslikMap, sonuc)
-				}
-			}
-		}
-		cacheSutunBilgi(cacheFilename, &sb)
-	}
-
-	// siralanmis basliklarla print
-	w, closeFile := openFile("cezaeviSandiklar", isCB)
-	defer closeFile()
-	pc := sb.FprintHeader(w, skippedColumnsFn(isCB))
-	for cevIdx, cev := range cevreler {
-		fmt.Printf("Cezaevi sandik verileri yaziliyor (cb=%v) (%d / %d secim cevresi) %s [%s]\n",
-			isCB, cevIdx+1, len(cevreler), cev.IlADI, memUsage())
-		cevColNameBaslikMap := colNameBaslikMap(cevBas[cevIdx], true)
-		for _, ilce := range src.IlceListesi(c, cev, st, cezaeviSandikTuru) {
-			for _, sonuc := range src.SecimSandikSonucListesi(c, src.CezaeviSonucParams(ilce, st)) {
-				pc.FprintRow(w, sb.addRow(cevColNameBaslikMap, sonuc))
-			}
-		}
-	}
-	fmt.Printf("Cezaevi sandik verileri dosyaya yazildi (cb=%v).\n", isCB)
-}
-
 func getSutunBilgiFromCache(fn string, sb *SutunBilgi) bool {
 	b, err := os.ReadFile(fn)
 	return err == nil && json.Unmarshal(b, &sb) == nil && len(sb.Names) != 0
@@ -479,8 +389,8 @@ func getSutunBilgiFromCache(fn string, sb *SutunBilgi) bool {
 
 func cacheSutunBilgi(fn string, sb *SutunBilgi) {
 	if b, err := json.Marshal(sb); err != nil {
-		log.Printf("WARN: cannot marshal sutunBilgi: %v\n", err)
-	} else if err = os.WriteFile(fn, b, 0o666); err != nil {
-		log.Printf("WARN: cannot write cache file %s: %v\n", fn, err)
+		logx.Warn("cannot marshal sutunBilgi", logx.Err(err))
+	} else if err = os.WriteFile(fn, b, filePerm); err != nil {
+		logx.Warn("cannot write cache file", logx.F("dosya", fn), logx.Err(err))
 	}
 }
@@ -0,0 +1,646 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/secim/src"
+	"github.com/secim/src/client"
+	"github.com/secim/src/logx"
+	"github.com/secim/src/progress"
+	"github.com/secim/src/testserver"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// sandik komutunu sahte kokpit'e karsi uctan uca calistirir
+func TestSandikKomutuUctanUca(t *testing.T) {
+	f := testserver.Default()
+	s := testserver.New(f)
+	defer s.Close()
+	// sutun onbellegi cwd'deki cache/ altina yazilir
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	// ilk istekler basarisiz olsa da calisma tamamlanmali
+	s.Inject("getGumrukList", testserver.FaultStatus, 1)
+	s.Inject("getSecimSandikSonucList", testserver.FaultMalformed, 1)
+	sandikKomutu(context.Background(), []string{
+		"-api-url", s.URL, "-progress", "off", "-log-level", "warn", "-out-root", dir,
+	})
+
+	want := map[string]int{
+		"sandiklar":        f.SandikCount(src.SandikTuruIlce),
+		"cezaeviSandiklar": f.SandikCount(src.SandikTuruCezaevi),
+		"disTemsSandiklar": f.SandikCount(src.SandikTuruDisTemsilcilik),
+		"gumrukSandiklar":  f.SandikCount(src.SandikTuruGumruk),
+	}
+	for scope, n := range want {
+		for _, isCB := range []bool{false, true} {
+			files, err := filepath.Glob(filepath.Join(dir, "output", scope+cbPrefix(isCB)+"-*.csv"))
+			if err != nil || len(files) != 1 {
+				t.Fatalf("%s%s: got output files %v, err %v", scope, cbPrefix(isCB), files, err)
+			}
+			rows := readCSV(t, files[0])
+			if got := len(rows) - 1; got != n {
+				t.Errorf("%s: got %d rows, want %d", files[0], got, n)
+			}
+			// ilk sutun tekil sandik anahtari
+			anahtarlar := make(map[string]bool)
+			for _, row := range rows[1:] {
+				if !strings.HasPrefix(row[0], fmt.Sprintf("%d/%d/", src.SecimID, secimTurID(isCB))) || anahtarlar[row[0]] {
+					t.Errorf("%s: bad or repeated key %q", files[0], row[0])
+				}
+				anahtarlar[row[0]] = true
+			}
+			// mv ciktilarinda bagimsiz adaylar atlanir
+			if has := strings.Contains(strings.Join(rows[0], ","), "BAĞIMSIZ X"); has != isCB {
+				t.Errorf("%s: bagimsiz column present = %v", files[0], has)
+			}
+		}
+	}
+}
+
+func readCSV(t *testing.T, fn string) [][]string {
+	t.Helper()
+	f, err := os.Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		t.Fatalf("%s: %v", fn, err)
+	}
+	return rows
+}
+
+// tutanak komutu sadece secilen birimin secilen sandiklarini yazmali
+func TestTutanakKomutu(t *testing.T) {
+	s := testserver.New(testserver.Default())
+	defer s.Close()
+	dir := t.TempDir()
+
+	for _, tc := range []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"ilce", []string{"-il", "ANKARA", "-ilce", "CANKAYA", "-no", "2"}, []string{"2"}},
+		{"ilceAralik", []string{"-il", "6", "-ilce", "çankaya", "-no", "1", "-son", "2"}, []string{"1", "2"}},
+		{"cezaevi", []string{"-il", "ANKARA", "-ilce", "815", "-cezaevi", "-no", "1001"}, []string{"1001"}},
+		{"temsilcilik", []string{"-ulke", "ALMANYA", "-temsilcilik", "berlin bk", "-no", "1"}, []string{"1"}},
+		{"gumruk", []string{"-gumruk", "KAPIKULE", "-no", "1"}, []string{"1"}},
+		{"yok", []string{"-il", "ANKARA", "-ilce", "CANKAYA", "-no", "5"}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fn := filepath.Join(dir, tc.name+".csv")
+			tutanakKomutu(context.Background(), append([]string{
+				"-api-url", s.URL, "-log-level", "error", "-out-root", dir, "-o", fn,
+			}, tc.args...))
+			rows := readCSV(t, fn)
+			col := -1
+			for i, h := range rows[0] {
+				if h == "SANDIK NO" {
+					col = i
+				}
+			}
+			var got []string
+			for _, row := range rows[1:] {
+				if col < 0 {
+					t.Fatalf("no SANDIK NO column in %v", rows[0])
+				}
+				got = append(got, row[col])
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("got sandik nos %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// ortusen sorgularda ayni sandik ikinci kez gelince tekrar raporlanmali
+func TestSandikDefteri(t *testing.T) {
+	d := sandikDefteri{gorulen: make(map[string]sandikTekrari)}
+	ilce := src.Ilce{IlID: 6, IlceID: 815, SecimCEVRESIID: 404520}
+	row := map[string]any{src.KeySandikNO: 1001.0, src.KeySandikID: 5.0}
+	if d.kaydet(src.CezaeviSonucParams(ilce, 8).Kimlik(row, 0), "cezaeviSandiklarMV") {
+		t.Fatal("first box reported as duplicate")
+	}
+	if d.kaydet(src.IlceSonucParams(ilce, 9).Kimlik(row, 0), "sandiklarCB") {
+		t.Error("cb box reported as duplicate of mv box")
+	}
+	if !d.kaydet(src.IlceSonucParams(ilce, 8).Kimlik(row, 0), "sandiklarMV") {
+		t.Error("cezaevi box under ilce not reported")
+	}
+	idsiz := map[string]any{src.KeySandikNO: 1002.0}
+	d.kaydet(src.CezaeviSonucParams(ilce, 8).Kimlik(idsiz, 0), "cezaeviSandiklarMV")
+	if !d.kaydet(src.IlceSonucParams(ilce, 8).Kimlik(idsiz, 3), "sandiklarMV") {
+		t.Error("cezaevi box without sandik id under ilce not reported")
+	}
+	// no, rumuz ve id'si gelmeyen satirlar birbirinin tekrari sayilmamali
+	for i := 0; i < 3; i++ {
+		bos := map[string]any{src.KeySandikNO: nil, src.KeySandikRUMUZ: "", "parti_1": float64(i)}
+		if d.kaydet(src.IlceSonucParams(ilce, 8).Kimlik(bos, i), "sandiklarMV") {
+			t.Errorf("row %d without identity reported as duplicate", i)
+		}
+	}
+}
+
+func TestExportGeoKomutu(t *testing.T) {
+	s := testserver.New(testserver.Default())
+	defer s.Close()
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "geo.csv")
+	exportGeoKomutu(context.Background(), []string{
+		"-api-url", s.URL, "-log-level", "error", "-out-root", dir, "-o", fn,
+	})
+	ust := make(map[string]string)
+	for _, row := range readCSV(t, fn)[1:] {
+		ust[row[0]] = row[4]
+	}
+	for id, want := range map[string]string{
+		"il-6": "", "cevre-404520": "il-6", "ilce-815": "cevre-404520", "muhtarlik-101": "ilce-815",
+		"ulke-9988": "", "temsilcilik-14": "ulke-9988", "gumruk-7": "ilce-1101",
+	} {
+		got, ok := ust[id]
+		if !ok {
+			t.Errorf("%s missing", id)
+		} else if got != want {
+			t.Errorf("%s parent = %q, want %q", id, got, want)
+		}
+	}
+	if len(ust) != 11 {
+		t.Errorf("got %d units, want 11", len(ust))
+	}
+}
+
+func TestGeojsonKomutu(t *testing.T) {
+	s := testserver.New(testserver.Default())
+	defer s.Close()
+	dir := t.TempDir()
+	sinir := filepath.Join(dir, "iller.geojson")
+	if err := os.WriteFile(sinir, []byte(`{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "properties": {"name": "ankara"}, "geometry": {"type": "Point", "coordinates": [32.8, 39.9]}},
+		{"type": "Feature", "properties": {"name": "Izmir"}, "geometry": null},
+		{"type": "Feature", "properties": {"name": "Bursa"}, "geometry": null}
+	]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fn := filepath.Join(dir, "harita.geojson")
+	geojsonKomutu(context.Background(), []string{
+		"-api-url", s.URL, "-log-level", "error", "-out-root", dir, "-sinir", sinir, "-o", fn,
+	})
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fc struct {
+		Features []struct {
+			Properties map[string]any  `json:"properties"`
+			Geometry   json.RawMessage `json:"geometry"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(b, &fc); err != nil {
+		t.Fatal(err)
+	}
+	if len(fc.Features) != 3 {
+		t.Fatalf("got %d features", len(fc.Features))
+	}
+	ankara := fc.Features[0]
+	// 3 ilce sandigi ve 1 cezaevi sandigi
+	if ankara.Properties["kazanan"] != "A PARTİSİ" || ankara.Properties["sandik_sayisi"] != 4.0 {
+		t.Errorf("unexpected ankara properties %v", ankara.Properties)
+	}
+	if !strings.Contains(string(ankara.Geometry), "32.8") {
+		t.Errorf("geometry not kept: %s", ankara.Geometry)
+	}
+	if fc.Features[1].Properties["kokpit_il_id"] != 35.0 || fc.Features[2].Properties["eslesti"] != false {
+		t.Error("izmir / bursa not matched as expected")
+	}
+}
+
+// ilerlemeIstemcisi her sandik listesi isteginde gorevin ilerlemesini kaydeder
+type ilerlemeIstemcisi struct {
+	client.Client
+	t     *progress.Task
+	mu    sync.Mutex
+	anlik [][2]int
+}
+
+func (c *ilerlemeIstemcisi) Request(ctx context.Context, uri string, resp any) error {
+	if strings.Contains(uri, "getSecimSandikSonucList") {
+		done, total := c.t.Counts()
+		c.mu.Lock()
+		c.anlik = append(c.anlik, [2]int{done, total})
+		c.mu.Unlock()
+	}
+	return c.Client.Request(ctx, uri, resp)
+}
+
+// iki turlu kapsamda toplam bastan verilmeli; ilk tur bitince %100 olmamali.
+// Birim sayaci birimin isteklerinden once artar.
+func TestKapsamIlerlemesi(t *testing.T) {
+	f := testserver.Default()
+	s := testserver.New(f)
+	defer s.Close()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+	if err = os.Mkdir("cache", dirPerm); err != nil {
+		t.Fatal(err)
+	}
+	d, err := client.Redirect(http.DefaultClient, s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logx.SetDefault(logx.Default())
+	logx.SetDefault(logx.New(io.Discard, logx.LevelError, logx.FormatText))
+
+	n := len(f.Iller[src.SandikTuruIlce])
+	for _, tc := range []struct {
+		name   string
+		turlar int
+	}{{"onbelleksiz", 2}, {"onbellekli", 1}} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &ilerlemeIstemcisi{Client: client.From(d), t: progress.New(io.Discard, false).Task("sandiklarMV")}
+			var wg sync.WaitGroup
+			wg.Add(1)
+			kapsamCek[src.Il](context.Background(), c, &wg, false, c.t, yurticiKapsami)
+			if len(c.anlik) == 0 {
+				t.Fatal("no sandik requests")
+			}
+			for _, a := range c.anlik {
+				if a[1] != tc.turlar*n || a[0] > a[1] {
+					t.Fatalf("progress %d/%d while fetching, want total %d", a[0], a[1], tc.turlar*n)
+				}
+			}
+			if done, total := c.t.Counts(); done != total {
+				t.Errorf("finished at %d/%d", done, total)
+			}
+		})
+	}
+}
+
+// watch sadece sayaclari degisen cevreleri, yurt disi sayaclari degisince de
+// dis temsilcilik ve gumruk sandiklarini yeniden cekmeli
+func TestIzleyici(t *testing.T) {
+	f := testserver.Default()
+	s := testserver.New(f)
+	defer s.Close()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+	// onceki testlerin -out-root'u kalmasin
+	defer func(a ciktiAyari) { cikti = a }(cikti)
+	cikti = ciktiAyari{kok: ".", sablon: defaultSablon}
+	d, err := client.Redirect(http.DefaultClient, s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logx.SetDefault(logx.Default())
+	logx.SetDefault(logx.New(io.Discard, logx.LevelError, logx.FormatText))
+
+	ctx := context.Background()
+	iz := newIzleyici(ctx, client.From(d), false)
+	// yeniDosyalar onceki turdan beri output/izle'ye yazilan dosyalarin kapsamlari
+	yazilan := make(map[string]bool)
+	yeniDosyalar := func() []string {
+		files, err := filepath.Glob(filepath.Join("output", "izle", "*.csv"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var l []string
+		for _, fn := range files {
+			if !yazilan[fn] {
+				yazilan[fn] = true
+				l = append(l, strings.SplitN(filepath.Base(fn), "MV-", 2)[0])
+			}
+		}
+		return l
+	}
+	izmir := f.Iller[src.SandikTuruIlce][1].SecimCEVRESIID
+	for i, tc := range []struct {
+		name     string
+		guncelle func(*testserver.Fixture)
+		want     []string
+	}{
+		{"ilk", nil, []string{"cevre404520", "cevre404600", "disTemsSandiklar", "gumrukSandiklar"}},
+		{"degismedi", nil, nil},
+		{"cevre", func(f *testserver.Fixture) {
+			cev := f.Cevreler[izmir]
+			cev.AcilanSandikSayisi++
+			cev.Version = "v2"
+			f.Cevreler[izmir] = cev
+			f.MV.Turkiye.AcilanSandikSayisi++
+		}, []string{"cevre404600"}},
+		{"yurtdisi", func(f *testserver.Fixture) {
+			f.MV.Yurtdisi.AcilanSandikSayisi++
+			f.MV.Yurtdisi.Version = "v3"
+		}, []string{"disTemsSandiklar", "gumrukSandiklar"}},
+	} {
+		if tc.guncelle != nil {
+			s.Update(tc.guncelle)
+		}
+		iz.yokla(ctx, i+1)
+		if got := yeniDosyalar(); strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("%s: wrote %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	files, err := filepath.Glob(filepath.Join("output", "izle", "disTemsSandiklarMV-*.csv"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no abroad snapshot: %v", err)
+	}
+	if got, want := len(readCSV(t, files[0]))-1, f.SandikCount(src.SandikTuruDisTemsilcilik); got != want {
+		t.Errorf("%s: got %d rows, want %d", files[0], got, want)
+	}
+}
+
+// -api-url ile gelen yanitlar ayna adresiyle ve -out-root altinda onbelleklenmeli;
+// baska bir aynaya veya kokpit'e onlarin yaniti verilmemeli
+func TestHTTPOnbellegiAyna(t *testing.T) {
+	s1, s2 := testserver.New(testserver.Default()), testserver.New(testserver.Default())
+	defer s1.Close()
+	defer s2.Close()
+	dir := t.TempDir()
+	defer func(a ciktiAyari) { cikti = a }(cikti)
+	defer logx.SetDefault(logx.Default())
+
+	ulkeler := func(s *testserver.Server) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		newClient := clientFlags(fs)
+		setupOutput := outputFlags(fs)
+		parseFlags(fs, []string{"-api-url", s.URL, "-http-cache", "-out-root", dir, "-log-level", "error"})
+		setupOutput()
+		src.UlkeListesi(context.Background(), newClient())
+	}
+	ulkeler(s1)
+	ulkeler(s2)
+	ulkeler(s1)
+	if n1, n2 := s1.Requests("getUlkeList"), s2.Requests("getUlkeList"); n1 != 1 || n2 != 1 {
+		t.Errorf("got %d and %d requests, want one per mirror", n1, n2)
+	}
+	l, err := client.NewDiskCache(filepath.Join(dir, httpCacheDir), nil, client.DefaultCacheRules).Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts := make(map[string]bool)
+	for _, e := range l {
+		u, err := url.Parse(e.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hosts[u.Host] = true
+	}
+	if len(l) != 2 || !hosts[s1.Listener.Addr().String()] || !hosts[s2.Listener.Addr().String()] {
+		t.Errorf("cache entries %v, want one per mirror", l)
+	}
+}
+
+// openCikti ile yazilan her bicimde manifest satir ve sutunlari doldurmali
+func TestCiktiOzeti(t *testing.T) {
+	dir := t.TempDir()
+	defer func(a ciktiAyari) { cikti = a }(cikti)
+	cikti = ciktiAyari{kok: dir, sablon: defaultSablon}
+	defer logx.SetDefault(logx.Default())
+	logx.SetDefault(logx.New(io.Discard, logx.LevelError, logx.FormatText))
+
+	for _, tc := range []struct {
+		ext, icerik string
+		satir       int
+		sutunlar    string
+	}{
+		{"csv", "ID,AD\n1,a\n2,\"b,c\"\n", 2, "ID,AD"},
+		{"csv", anahtarSutunu + ",\"A\",\"B\"\n60792/8/1,1,2\n", 1, "A,B"},
+		{"json", `[{"a": 1, "b": [1, 2]}, {"a": 2, "c": {"d": 1}}]`, 2, "a,b,c"},
+		{"json", `{"sayilar": {"il": 1}, "birimler": [{"id": "il-6"}], "diger": [{"x": 1}, {"x": 2}]}`, 1, "id"},
+		{"geojson", `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": null,
+			"properties": {"ad": "ANKARA", "oy": 3}}]}`, 1, "ad,oy"},
+	} {
+		w, fn, closeOut := openCikti(filepath.Join(dir, "cikti."+tc.ext), "test", false, tc.ext)
+		if _, err := io.WriteString(w, tc.icerik); err != nil {
+			t.Fatal(err)
+		}
+		closeOut()
+		kosu.mu.Lock()
+		c := kosu.ciktilar[len(kosu.ciktilar)-1]
+		kosu.mu.Unlock()
+		if c.Dosya != fn || c.Satir != tc.satir || strings.Join(c.Sutunlar, ",") != tc.sutunlar {
+			t.Errorf("%s: got %s %d rows %v, want %d rows %s", tc.icerik, c.Dosya, c.Satir, c.Sutunlar,
+				tc.satir, tc.sutunlar)
+		}
+		if sum := sha256.Sum256([]byte(tc.icerik)); c.SHA256 != hex.EncodeToString(sum[:]) ||
+			c.Bayt != int64(len(tc.icerik)) {
+			t.Errorf("%s: sha256 %s, %d bytes", tc.icerik, c.SHA256, c.Bayt)
+		}
+	}
+}
+
+// sikistirilan il dosyasinin manifest'teki sha256 ve boyutu diskteki
+// sikistirilmis dosyanin, satir ve sutunlari sikistirmadan onceki csv'nin olmali
+func TestSikistirilmisIlDosyasi(t *testing.T) {
+	dir := t.TempDir()
+	defer func(a ciktiAyari) { cikti = a }(cikti)
+	defer logx.SetDefault(logx.Default())
+	logx.SetDefault(logx.New(io.Discard, logx.LevelError, logx.FormatText))
+
+	icerik := "ID,AD,OY\n" + strings.Repeat("1,a,\n2,\"b,c\",\n", 500)
+	for _, tur := range []string{"gzip", "zstd"} {
+		cikti = ciktiAyari{kok: dir, sablon: defaultSablon, sikistirma: tur}
+		w, closeOut := openIlFile(context.Background(), "sandiklar", false, "")
+		if _, err := io.WriteString(w, icerik); err != nil {
+			t.Fatal(err)
+		}
+		closeOut()
+		kosu.mu.Lock()
+		c := kosu.ciktilar[len(kosu.ciktilar)-1]
+		kosu.mu.Unlock()
+		if !strings.HasSuffix(c.Dosya, sikistirmaUzantisi[tur]) {
+			t.Errorf("%s: got file %s", tur, c.Dosya)
+		}
+		b, err := os.ReadFile(c.Dosya)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sum := sha256.Sum256(b); c.SHA256 != hex.EncodeToString(sum[:]) || c.Bayt != int64(len(b)) {
+			t.Errorf("%s: got sha256 %s, %d bytes, want those of the compressed file (%d bytes)", tur, c.SHA256,
+				c.Bayt, len(b))
+		}
+		if len(b) >= len(icerik) {
+			t.Errorf("%s: %d bytes compressed to %d", tur, len(icerik), len(b))
+		}
+		if c.Satir != 1000 || strings.Join(c.Sutunlar, ",") != "ID,AD,OY" {
+			t.Errorf("%s: got %d rows %v, want 1000 rows ID,AD,OY", tur, c.Satir, c.Sutunlar)
+		}
+		r, err := acSikistirilmis(c.Dosya)
+		if err != nil {
+			t.Fatal(err)
+		}
+		acik, err := io.ReadAll(r)
+		if e := r.Close(); err == nil {
+			err = e
+		}
+		if err != nil || string(acik) != icerik {
+			t.Errorf("%s: decompressed content differs, err %v", tur, err)
+		}
+	}
+}
+
+// sandik -o - secilen tek kapsami dosya yazmadan stdout'a yazmali
+func TestSandikStdout(t *testing.T) {
+	f := testserver.Default()
+	s := testserver.New(f)
+	defer s.Close()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+	defer func(a ciktiAyari) { cikti = a }(cikti)
+	out, err := os.Create(filepath.Join(t.TempDir(), "stdout.csv.zst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(f *os.File) { os.Stdout = f }(os.Stdout)
+	os.Stdout = out
+
+	sandikKomutu(context.Background(), []string{"-api-url", s.URL, "-progress", "off", "-log-level", "warn",
+		"-out-root", dir, "-o", "-", "-kapsam", "disTemsSandiklar", "-cb", "-compress", "zstd"})
+	if err = out.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := acSikistirilmis(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = r.Close() }()
+	c := csv.NewReader(r)
+	c.FieldsPerRecord = -1
+	rows, err := c.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(rows)-1, f.SandikCount(src.SandikTuruDisTemsilcilik); got != want {
+		t.Errorf("got %d rows, want %d", got, want)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "output", "*")); len(files) != 0 {
+		t.Errorf("got output files %v", files)
+	}
+
+	// tek akis il bazli bolunemez
+	args, err := json.Marshal([]string{"sandik", "-api-url", s.URL, "-log-level", "error", "-out-root", dir,
+		"-o", "-", "-split-il"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFatalManifesti$")
+	cmd.Env = append(os.Environ(), "SECIM_TEST_ARGS="+string(args))
+	if err = cmd.Run(); err == nil {
+		t.Error("-o - -split-il did not fail")
+	} else if e, ok := err.(*exec.ExitError); !ok || e.ExitCode() != 1 {
+		t.Errorf("got %v, want exit status 1", err)
+	}
+}
+
+// Fatal'a dusen calisma tamam=false manifest'i temp/'e yazip 1 ile cikmali
+func TestFatalManifesti(t *testing.T) {
+	if args := os.Getenv("SECIM_TEST_ARGS"); args != "" {
+		// alt surec: komutu main uzerinden calistir
+		var l []string
+		if err := json.Unmarshal([]byte(args), &l); err != nil {
+			t.Fatal(err)
+		}
+		os.Args = append([]string{"secim"}, l...)
+		main()
+		return
+	}
+	s := testserver.New(testserver.Default())
+	defer s.Close()
+	s.Inject("getSecimSandikSonucList", testserver.FaultNotFound, 10)
+	dir := t.TempDir()
+	args, err := json.Marshal([]string{"tutanak", "-api-url", s.URL, "-log-level", "error", "-out-root", dir,
+		"-il", "ANKARA", "-ilce", "CANKAYA", "-no", "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFatalManifesti$")
+	cmd.Env = append(os.Environ(), "SECIM_TEST_ARGS="+string(args))
+	if err = cmd.Run(); err == nil {
+		t.Fatal("command did not fail")
+	} else if e, ok := err.(*exec.ExitError); !ok || e.ExitCode() != 1 {
+		t.Fatalf("got %v, want exit status 1", err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "temp", "manifest-*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("got manifests %v, err %v", files, err)
+	}
+	b, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m manifest
+	if err = json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Tamam || m.Komut != "tutanak" {
+		t.Errorf("got komut %q tamam %v, want an incomplete tutanak run", m.Komut, m.Tamam)
+	}
+}
+
+// metrik ozeti json loglarda her metrik icin gecerli bir kayit olmali
+func TestMetrikOzetiJSON(t *testing.T) {
+	var buf bytes.Buffer
+	defer logx.SetDefault(logx.Default())
+	logx.SetDefault(logx.New(&buf, logx.LevelInfo, logx.FormatJSON))
+	rowsWritten.Inc("ozetTestiMV")
+	metrikOzeti()
+	var bulundu bool
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("not a json record: %q", line)
+		}
+		if m["metrik"] == "kokpit_rows_written_total" && m["scope"] == "ozetTestiMV" {
+			bulundu = m["deger"] == 1.0
+		}
+	}
+	if !bulundu {
+		t.Errorf("row counter missing from summary:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,459 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/secim/src"
+	"github.com/secim/src/client"
+	"github.com/secim/src/logx"
+	"github.com/secim/src/registry"
+	"github.com/secim/src/schema"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// version derlemede -ldflags "-X main.version=v1.2.3" ile verilir
+var version = "dev"
+
+// aracSurumu surumu ve varsa derlendigi git commit'ini doner
+func aracSurumu() string {
+	v := version
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		var rev, dirty string
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision":
+				rev = s.Value
+			case s.Key == "vcs.modified" && s.Value == "true":
+				dirty = "-dirty"
+			}
+		}
+		if rev != "" {
+			v += " (" + rev + dirty + ")"
+		}
+	}
+	return v
+}
+
+// region kosu
+
+// ciktiKaydi bu calismada yazilan bir dosya. Satir ve Sutunlar csv'de
+// baslik disindaki satirlar ve anahtar disindaki sutunlar; json'da kayit
+// listesinin (ust dizi veya ust nesnenin ilk dizi alani) elemanlari ve
+// anahtarlaridir, geojson'da features ve properties anahtarlari.
+type ciktiKaydi struct {
+	Dosya     string   `json:"dosya"`
+	Tamam     bool     `json:"tamam"`
+	Kapsam    string   `json:"kapsam,omitempty"`
+	SecimTuru int      `json:"secimTuru,omitempty"`
+	SHA256    string   `json:"sha256"`
+	Bayt      int64    `json:"bayt"`
+	Satir     int      `json:"satir,omitempty"`
+	Sutunlar  []string `json:"sutunlar,omitempty"`
+}
+
+// birimHatasi tamamlanamayan bir birim (il, ulke, gumruk kapisi)
+type birimHatasi struct {
+	Kapsam string `json:"kapsam"`
+	Birim  string `json:"birim,omitempty"`
+	Hata   string `json:"hata"`
+}
+
+// kosuKaydi calismanin manifest'e yazilacak bilgileri
+type kosuKaydi struct {
+	mu        sync.Mutex
+	komut     string
+	baslangic time.Time
+	kapsamlar []string
+	ciktilar  []ciktiKaydi
+	basarisiz []birimHatasi
+	tekrarlar []sandikTekrari
+	dc        *client.DedupClient
+	sema      []schema.Drift
+	// secim turu basina calisma boyunca cekilen basliklar
+	basliklar  map[int]*registry.Registry
+	catismalar []registry.Conflict
+}
+
+var kosu = kosuKaydi{baslangic: time.Now()}
+
+func (k *kosuKaydi) setKomut(komut string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.komut = komut
+}
+
+// setClient istek sayilari icin komutun istemcisini kaydeder
+func (k *kosuKaydi) setClient(dc *client.DedupClient) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.dc = dc
+}
+
+// basladi komutun istemciyi kurup ise basladigini bildirir; bayrak
+// hatalari gibi erken Fatal'larda manifest yazilmaz
+func (k *kosuKaydi) basladi() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.dc != nil
+}
+
+// tekillestirme istemcinin tekillestirme istatistiklerini doner
+func (k *kosuKaydi) tekillestirme() client.DedupStats {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.dc == nil {
+		return client.DedupStats{}
+	}
+	return k.dc.Stats()
+}
+
+func (k *kosuKaydi) kapsamEkle(scope string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.kapsamlar = append(k.kapsamlar, scope)
+}
+
+func (k *kosuKaydi) ciktiEkle(c ciktiKaydi) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.ciktilar = append(k.ciktilar, c)
+}
+
+func (k *kosuKaydi) semaEkle(l []schema.Drift) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.sema = l
+}
+
+func (k *kosuKaydi) tekrarEkle(t sandikTekrari) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.tekrarlar = append(k.tekrarlar, t)
+}
+
+// baslikKaydi secim turunun calisma boyunca paylasilan baslik kaydini doner;
+// mv ve cb basliklari ayni cevre icin farkli oldugu icin ayri tutulur
+func (k *kosuKaydi) baslikKaydi(st int) *registry.Registry {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.basliklar == nil {
+		k.basliklar = make(map[int]*registry.Registry)
+	}
+	r, ok := k.basliklar[st]
+	if !ok {
+		r = registry.New()
+		k.basliklar[st] = r
+	}
+	return r
+}
+
+func (k *kosuKaydi) catismaEkle(l []registry.Conflict) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.catismalar = append(k.catismalar, l...)
+}
+
+func (k *kosuKaydi) hataEkle(h birimHatasi) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.basarisiz = append(k.basarisiz, h)
+}
+
+// kapsamKurtar kapsam goroutine'lerinde src.Recover yerine defer edilir; iptalde o an islenen birimi basarisiz olarak kaydeder.
+// recover dogrudan defer edilen fonksiyonda cagrilmali.
+func kapsamKurtar(scope string, birim *string) {
+	if r := recover(); r != nil {
+		c, ok := r.(src.Canceled)
+		if !ok {
+			panic(r)
+		}
+		kosu.hataEkle(birimHatasi{Kapsam: scope, Birim: *birim, Hata: c.Error()})
+	}
+}
+
+// manifest yayinlanan verinin ucuncu kisilerce dogrulanabilmesi icin
+// calismanin tum ciktilarini ve kaynagini listeler
+type manifest struct {
+	Arac      string        `json:"arac"`
+	Komut     string        `json:"komut"`
+	SecimID   int           `json:"secimId"`
+	Baslangic time.Time     `json:"baslangic"`
+	Bitis     time.Time     `json:"bitis"`
+	Tamam     bool          `json:"tamam"`
+	Kapsamlar []string      `json:"kapsamlar"`
+	Istekler  istekSayilari `json:"istekler"`
+	Ciktilar  []ciktiKaydi  `json:"ciktilar"`
+	Basarisiz []birimHatasi `json:"basarisiz"`
+	// ortusen sorgularda ikinci kez gelen sandiklar
+	Tekrarlanan []sandikTekrari `json:"tekrarlanan,omitempty"`
+	// -schema-check acikken api yanitlarindaki sema farklari
+	SemaFarklari []schema.Drift `json:"semaFarklari,omitempty"`
+	// ayni cevrede cakisan sutun adlari / adlar; sutunda ilk, adda son baslik kullanildi
+	BaslikCatismalari []registry.Conflict `json:"baslikCatismalari,omitempty"`
+}
+
+type istekSayilari struct {
+	HTTP            float64 `json:"http"`
+	YenidenDeneme   float64 `json:"yenidenDeneme"`
+	Tekillestirilen int64   `json:"tekillestirilen"`
+	IstemciIstegi   int64   `json:"istemciIstegi"`
+}
+
+// manifestYaz calisma bir dosya yazdiysa veya tamamlanmadiysa (yarida
+// kesildi ya da Fatal'a dustu) manifest'i yazar: tamamlanan calismalar icin
+// <kok>/output/, digerleri icin <kok>/temp/ altina.
+func (k *kosuKaydi) manifestYaz(tamam bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(k.ciktilar) == 0 && tamam {
+		return
+	}
+	now := time.Now().In(loc)
+	m := manifest{
+		Arac: aracSurumu(), Komut: k.komut, SecimID: src.SecimID,
+		Baslangic: k.baslangic.In(loc), Bitis: now, Tamam: tamam,
+		Kapsamlar:         append([]string{}, k.kapsamlar...),
+		Ciktilar:          append([]ciktiKaydi{}, k.ciktilar...),
+		Basarisiz:         append([]birimHatasi{}, k.basarisiz...),
+		Tekrarlanan:       append([]sandikTekrari{}, k.tekrarlar...),
+		SemaFarklari:      k.sema,
+		BaslikCatismalari: append([]registry.Conflict(nil), k.catismalar...),
+	}
+	sort.Strings(m.Kapsamlar)
+	sort.Slice(m.Ciktilar, func(i, j int) bool { return m.Ciktilar[i].Dosya < m.Ciktilar[j].Dosya })
+	sort.Slice(m.Basarisiz, func(i, j int) bool { return m.Basarisiz[i].Kapsam < m.Basarisiz[j].Kapsam })
+	sort.Slice(m.Tekrarlanan, func(i, j int) bool { return m.Tekrarlanan[i].Anahtar < m.Tekrarlanan[j].Anahtar })
+	m.Istekler.HTTP, m.Istekler.YenidenDeneme = client.Totals()
+	if k.dc != nil {
+		st := k.dc.Stats()
+		m.Istekler.IstemciIstegi, m.Istekler.Tekillestirilen = st.Requests, st.Saved()
+	}
+	dir := cikti.outDir()
+	if !m.Tamam {
+		dir = cikti.tempDir()
+	}
+	fn := filepath.Join(dir, fmt.Sprintf("manifest-%s.json", now.Format("02-01-2006-15-04-05")))
+	buf, err := json.MarshalIndent(m, "", "  ")
+	if err == nil {
+		if err = os.MkdirAll(dir, dirPerm); err == nil {
+			err = os.WriteFile(fn, append(buf, '\n'), filePerm)
+		}
+	}
+	if err != nil {
+		logx.Error("cannot write manifest", logx.F("dosya", fn), logx.Err(err))
+	} else {
+		logx.Info("Manifest yazildi", logx.F("dosya", fn), logx.F("cikti", len(m.Ciktilar)),
+			logx.F("basarisiz", len(m.Basarisiz)))
+	}
+}
+
+// endregion
+// region ozetYazici
+
+// ozetYazici csv satirlarini ve basligi (json'da kayitlari) sikistirmadan
+// once, dosyaya giden baytlarin sha256'sini ve boyutunu sikistirmadan sonra
+// sayar; manifest'teki ozet diskteki dosyayla dogrulanabilir
+type ozetYazici struct {
+	z      io.WriteCloser
+	dosya  *hashYazici
+	satir  int
+	baslik []byte
+	sayac  *jsonSayaci
+}
+
+type hashYazici struct {
+	w io.Writer
+	h hash.Hash
+	n int64
+}
+
+func (o *hashYazici) Write(p []byte) (int, error) {
+	n, err := o.w.Write(p)
+	o.h.Write(p[:n])
+	o.n += int64(n)
+	return n, err
+}
+
+// newOzetYazici ext dosyanin bicimidir (csv, json, geojson)
+func newOzetYazici(w io.Writer, sikistirma, ext string) *ozetYazici {
+	d := &hashYazici{w: w, h: sha256.New()}
+	o := &ozetYazici{z: sikistir(d, sikistirma), dosya: d}
+	if ext == "json" || ext == "geojson" {
+		o.sayac = newJSONSayaci(ext == "geojson")
+	}
+	return o
+}
+
+func (o *ozetYazici) Write(p []byte) (int, error) {
+	n, err := o.z.Write(p)
+	if o.sayac != nil {
+		o.sayac.yaz(p[:n])
+		return n, err
+	}
+	if o.satir == 0 {
+		if i := bytes.IndexByte(p[:n], '\n'); i >= 0 {
+			o.baslik = append(o.baslik, p[:i]...)
+		} else {
+			o.baslik = append(o.baslik, p[:n]...)
+		}
+	}
+	o.satir += bytes.Count(p[:n], []byte{'\n'})
+	return n, err
+}
+
+// Close sikistiriciyi bosaltir; alttaki dosyayi kapatmaz
+func (o *ozetYazici) Close() error {
+	if o.sayac != nil {
+		o.sayac.kapat()
+	}
+	return o.z.Close()
+}
+
+// kayit dosya icin manifest kaydini doldurur; sandik csv'lerinin ilk sutunu
+// sandik anahtaridir
+func (o *ozetYazici) kayit(dosya, scope string, isCB, tamam bool) ciktiKaydi {
+	c := ciktiKaydi{
+		Dosya: dosya, Tamam: tamam, Kapsam: scope, SecimTuru: secimTurID(isCB),
+		SHA256: hex.EncodeToString(o.dosya.h.Sum(nil)), Bayt: o.dosya.n,
+	}
+	if o.sayac != nil {
+		c.Satir = o.sayac.satir
+		for k := range o.sayac.sutunlar {
+			c.Sutunlar = append(c.Sutunlar, k)
+		}
+		sort.Strings(c.Sutunlar)
+		return c
+	}
+	if o.satir > 0 {
+		c.Satir = o.satir - 1
+	}
+	if cols, err := csv.NewReader(bytes.NewReader(o.baslik)).Read(); err == nil {
+		if len(cols) != 0 && cols[0] == anahtarSutunu {
+			cols = cols[1:]
+		}
+		if len(cols) != 0 {
+			c.Sutunlar = cols
+		}
+	}
+	return c
+}
+
+// jsonSayaci yazilan json'i ayri bir goroutine'de akis halinde cozup
+// kayitlari ve anahtarlarini sayar; dosya bellege alinmaz
+type jsonSayaci struct {
+	pw       *io.PipeWriter
+	bitti    chan struct{}
+	geojson  bool
+	satir    int
+	sutunlar map[string]bool
+}
+
+func newJSONSayaci(geojson bool) *jsonSayaci {
+	pr, pw := io.Pipe()
+	s := &jsonSayaci{pw: pw, bitti: make(chan struct{}), geojson: geojson, sutunlar: make(map[string]bool)}
+	go func() {
+		defer close(s.bitti)
+		_ = s.say(json.NewDecoder(pr))
+		// cozulemeyen veya kalan baytlar yaziciyi bekletmesin
+		_, _ = io.Copy(io.Discard, pr)
+	}()
+	return s
+}
+
+func (s *jsonSayaci) yaz(p []byte) {
+	_, _ = s.pw.Write(p)
+}
+
+// kapat akisi bitirir ve sayimin bitmesini bekler
+func (s *jsonSayaci) kapat() {
+	_ = s.pw.Close()
+	<-s.bitti
+}
+
+func (s *jsonSayaci) say(dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == json.Delim('[') {
+		return s.kayitlar(dec)
+	}
+	if tok != json.Delim('{') {
+		return nil
+	}
+	// ilk dizi alani kayit listesidir, digerleri atlanir
+	bulundu := false
+	for dec.More() {
+		// alan adi, sonra degerin ilk token'i
+		if _, err = dec.Token(); err != nil {
+			return err
+		}
+		if tok, err = dec.Token(); err != nil {
+			return err
+		}
+		if tok == json.Delim('[') && !bulundu {
+			bulundu = true
+			err = s.kayitlar(dec)
+		} else {
+			err = atla(dec, tok)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kayitlar dizinin acilisindan sonra cagrilir; kapanisi da okur
+func (s *jsonSayaci) kayitlar(dec *json.Decoder) error {
+	for dec.More() {
+		var m map[string]json.RawMessage
+		if err := dec.Decode(&m); err != nil {
+			return err
+		}
+		s.satir++
+		if s.geojson {
+			var props map[string]json.RawMessage
+			_ = json.Unmarshal(m["properties"], &props)
+			m = props
+		}
+		for k := range m {
+			s.sutunlar[k] = true
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// atla ilk token'i okunmus degeri sonuna kadar okur
+func atla(dec *json.Decoder, tok json.Token) error {
+	derinlik := 0
+	for {
+		switch tok {
+		case json.Delim('['), json.Delim('{'):
+			derinlik++
+		case json.Delim(']'), json.Delim('}'):
+			derinlik--
+		}
+		if derinlik == 0 {
+			return nil
+		}
+		var err error
+		if tok, err = dec.Token(); err != nil {
+			return err
+		}
+	}
+}
+
+// endregion
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"github.com/secim/src/logx"
+	"github.com/secim/src/metrics"
+	"net/http"
+	"runtime"
+)
+
+var (
+	rowsWritten = metrics.NewCounter("kokpit_rows_written_total",
+		"Cikti dosyalarina yazilan sandik satirlari.", "scope")
+	scopesRunning = metrics.NewGauge("kokpit_scope_running",
+		"Kapsam goroutine'i calisiyorsa 1.", "scope")
+	_ = metrics.NewGaugeFunc("kokpit_goroutines", "Calisan goroutine sayisi.",
+		func() float64 { return float64(runtime.NumGoroutine()) })
+	_ = metrics.NewGaugeFunc("kokpit_heap_alloc_bytes", "Heap'te ayrilmis bayt.", func() float64 {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		return float64(m.Alloc)
+	})
+)
+
+// scopeRunning kapsami calisiyor olarak isaretler; donen fonksiyon defer edilir
+func scopeRunning(scope string) func() {
+	scopesRunning.Set(1, scope)
+	return func() { scopesRunning.Set(0, scope) }
+}
+
+// metricsFlags -metrics-addr bayragini ekler; donen fonksiyon Parse'tan sonra
+// adres verilmisse /metrics endpoint'ini arka planda baslatir
+func metricsFlags(fs *flag.FlagSet) func() {
+	addr := fs.String("metrics-addr", "", "prometheus /metrics endpoint adresi (ornek: :9100)")
+	return func() {
+		if *addr == "" {
+			return
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Default.Handler())
+		go func() {
+			logx.Info("Metrikler yayinda", logx.F("url", "http://"+*addr+"/metrics"))
+			if err := http.ListenAndServe(*addr, mux); err != nil {
+				logx.Warn("metrics endpoint kapandi", logx.Err(err))
+			}
+		}()
+	}
+}
+
+// metrikOzeti calisma sonunda her metrigi etiketleriyle ayri bir log
+// kaydi olarak yazar; json / logfmt loglari tek bicimde kalir (quiet modda yazilmaz)
+func metrikOzeti() {
+	if !logx.Enabled(logx.LevelInfo) {
+		return
+	}
+	for _, s := range metrics.Default.Summary() {
+		fields := []logx.Field{logx.F("metrik", s.Name)}
+		for _, l := range s.Labels {
+			fields = append(fields, logx.F(l.Name, l.Value))
+		}
+		if s.Type == "histogram" {
+			fields = append(fields, logx.F("sayi", s.Count), logx.F("ortalama", s.Value))
+		} else {
+			fields = append(fields, logx.F("deger", s.Value))
+		}
+		logx.Info("Metrik ozeti", fields...)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/secim/src"
+	"github.com/secim/src/logx"
+)
+
+// mvAgacKomutu milletvekili birim agacini duzlestirip csv veya json olarak yazar.
+// cevre verilmezse genel sonuclardaki turkiye ve yurtdisi agaclari yazilir.
+func mvAgacKomutu(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("mv-agac", flag.ExitOnError)
+	newClient := clientFlags(fs)
+	cevreID := fs.Int("cevre", 0, "secim cevresi id'si (0 = turkiye + yurtdisi)")
+	format := fs.String("format", "csv", "cikti formati: csv veya json")
+	out := fs.String("o", "", "cikti dosyasi (bos = -name-template ile output/ altinda, ornek mvAgacMV-<zaman>.<format>)")
+	setupOutput := outputFlags(fs)
+	parseFlags(fs, args)
+	setupOutput()
+	if *format != "csv" && *format != "json" {
+		logx.Fatal("gecersiz format", logx.F("format", *format))
+	}
+	c := newClient()
+
+	var roots []*src.DVOData
+	if *cevreID == 0 {
+		mv := src.GenelMVSonuclar(ctx, c)
+		roots = append(roots, &mv.Turkiye, &mv.Yurtdisi)
+	} else {
+		dd := src.CevreMVSonuclar(ctx, c, *cevreID)
+		roots = append(roots, &dd)
+	}
+
+	w, fn, closeOut := openCikti(*out, "mvAgac", false, *format)
+	var err error
+	if *format == "json" {
+		err = src.WriteDVOJSON(w, roots...)
+	} else {
+		err = src.WriteDVOCSV(w, roots...)
+	}
+	if err != nil {
+		logx.Fatal("cannot write to file", logx.F("dosya", fn), logx.Err(err))
+	}
+	closeOut()
+	logx.Info("Milletvekili birim agaci yazildi", logx.F("dosya", fn))
+}
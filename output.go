@@ -0,0 +1,306 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"flag"
+	"fmt"
+	"github.com/secim/src"
+	"github.com/secim/src/logx"
+	"github.com/secim/src/zstd"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// cikti dizinleri ve dosyalari icin izinler; sunucuda baska kullanicilar okuyamasin
+const (
+	dirPerm  = 0o750
+	filePerm = 0o640
+)
+
+// varsayilan sablon eski adlandirmayi korur: sandiklarCB-14-05-2023-23-04.csv
+const defaultSablon = "{scope}{type}-{timestamp}.{ext}"
+
+// ciktiAyari cikti kok dizini ve dosya adi sablonu. Dosyalar once
+// <kok>/temp/ altina yazilir, bitince ayni goreli yolla <kok>/output/'a tasinir.
+type ciktiAyari struct {
+	kok        string
+	sablon     string
+	ilBazli    bool
+	sikistirma string
+	// stdout sandik -o -: openIlFile dosya yerine stdout'a yazar
+	stdout bool
+}
+
+var cikti = ciktiAyari{kok: ".", sablon: defaultSablon}
+
+func (a ciktiAyari) tempDir() string {
+	return filepath.Join(a.kok, "temp")
+}
+
+func (a ciktiAyari) outDir() string {
+	return filepath.Join(a.kok, "output")
+}
+
+// outputFlags cikti bayraklarini ekler; donen fonksiyon Parse'tan sonra
+// ayarlari dogrular ve temp / output dizinlerini olusturur.
+// Sablon alanlari: {election} {type} {scope} {il} {timestamp} {ext}
+func outputFlags(fs *flag.FlagSet) func() {
+	kok := fs.String("out-root", ".", "temp/ ve output/ dizinlerinin olusturulacagi kok dizin")
+	sablon := fs.String("name-template", defaultSablon,
+		"dosya adi sablonu; ornek: {election}/{type}/{scope}/{timestamp}.{ext}")
+	ilBazli := fs.Bool("split-il", false, "yurt ici ve cezaevi sandiklarini her il icin ayri dosyaya yaz")
+	sikistirma := fs.String("compress", "",
+		"cikti sikistirmasi: none|gzip|zstd (bos = -o uzantisindan, yoksa none)")
+	return func() {
+		switch *sikistirma {
+		case "", sikistirmaYok, "gzip", "zstd":
+		default:
+			logx.Fatal("gecersiz sikistirma", logx.F("compress", *sikistirma))
+		}
+		a := ciktiAyari{kok: *kok, sablon: *sablon, ilBazli: *ilBazli, sikistirma: *sikistirma}
+		if a.ilBazli && !strings.Contains(a.sablon, "{il}") {
+			// il'ler ayni dosya adina yazmasin
+			a.sablon = strings.Replace(a.sablon, ".{ext}", "-{il}.{ext}", 1)
+			if !strings.Contains(a.sablon, "{il}") {
+				a.sablon += "-{il}"
+			}
+		}
+		if ornek := a.ad("sandiklar", true, "ankara", "csv", time.Now()); filepath.IsAbs(ornek) ||
+			ornek == ".." || strings.HasPrefix(ornek, ".."+string(filepath.Separator)) {
+			logx.Fatal("dosya adi sablonu cikti dizininin disina cikiyor", logx.F("sablon", a.sablon))
+		}
+		for _, dir := range []string{a.tempDir(), a.outDir()} {
+			if err := os.MkdirAll(dir, dirPerm); err != nil {
+				logx.Fatal("Cikti dizini olusturulamiyor!", logx.F("dizin", dir), logx.Err(err))
+			}
+		}
+		cikti = a
+	}
+}
+
+// ad sablonu doldurup temp / output dizinine goreli dosya yolunu doner
+func (a ciktiAyari) ad(title string, isCB bool, il, ext string, tm time.Time) string {
+	r := strings.NewReplacer(
+		"{election}", fmt.Sprint(src.SecimID),
+		"{type}", cbPrefix(isCB),
+		"{scope}", title,
+		"{il}", ilSlug(il),
+		"{timestamp}", tm.In(loc).Format("02-01-2006-15-04"),
+		"{ext}", ext+sikistirmaUzantisi[a.sikistirma],
+	)
+	return filepath.Clean(filepath.FromSlash(r.Replace(a.sablon)))
+}
+
+// ilSlug il adini dosya adina uygun ascii'ye cevirir: "İSTANBUL 1" -> "istanbul_1"
+func ilSlug(il string) string {
+	tr := strings.NewReplacer("ç", "c", "ğ", "g", "ı", "i", "ö", "o", "ş", "s", "ü", "u")
+	s := tr.Replace(strings.ToLowerSpecial(unicode.TurkishCase, il))
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r > unicode.MaxASCII:
+			return -1
+		}
+		return '_'
+	}, s)
+}
+
+// createFile ust dizinleri olusturup dosyayi guvenli izinlerle acar. Ayni
+// adla baska bir calismanin dosyasi varsa uzerine yazmaz, ada -2, -3 ... ekler.
+func createFile(fn string) (*os.File, string, error) {
+	if err := os.MkdirAll(filepath.Dir(fn), dirPerm); err != nil {
+		return nil, fn, err
+	}
+	ext := filepath.Ext(fn)
+	base := strings.TrimSuffix(fn, ext)
+	for i := 1; ; i++ {
+		name := fn
+		if i > 1 {
+			name = fmt.Sprintf("%s-%d%s", base, i, ext)
+		}
+		f, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, filePerm)
+		if !os.IsExist(err) {
+			return f, name, err
+		}
+	}
+}
+
+// tasi temp'teki bitmis dosyayi output/ altinda ayni goreli yola tasir.
+// Ayni adla bir dosya varsa uzerine yazmaz, ada -2, -3 ... ekler.
+func (a ciktiAyari) tasi(fn, ad string) (string, error) {
+	lastFn := filepath.Join(a.outDir(), ad)
+	if err := os.MkdirAll(filepath.Dir(lastFn), dirPerm); err != nil {
+		return lastFn, err
+	}
+	ext := filepath.Ext(lastFn)
+	base := strings.TrimSuffix(lastFn, ext)
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(lastFn); os.IsNotExist(err) {
+			break
+		}
+		lastFn = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+	return lastFn, os.Rename(fn, lastFn)
+}
+
+// region sikistirma
+
+const sikistirmaYok = "none"
+
+var sikistirmaUzantisi = map[string]string{"gzip": ".gz", "zstd": ".zst"}
+
+// sikistirmaOf bayrak verilmemisse dosya uzantisindan sikistirmayi secer
+func (a ciktiAyari) sikistirmaOf(fn string) string {
+	if a.sikistirma != "" {
+		return a.sikistirma
+	}
+	for tur, ext := range sikistirmaUzantisi {
+		if strings.HasSuffix(fn, ext) {
+			return tur
+		}
+	}
+	return sikistirmaYok
+}
+
+// sikistir w'ye sikistirarak yazan bir writer doner; Close sikistiriciyi
+// bosaltir ama w'yi kapatmaz
+func sikistir(w io.Writer, tur string) io.WriteCloser {
+	switch tur {
+	case "gzip":
+		return gzip.NewWriter(w)
+	case "zstd":
+		return zstd.NewWriter(w)
+	}
+	return nopWriteCloser{w}
+}
+
+// acSikistirilmis dosyayi acar; .gz ve .zst uzantili dosyalari acarak okur
+func acSikistirilmis(fn string) (io.ReadCloser, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	switch (ciktiAyari{}).sikistirmaOf(fn) {
+	case "gzip":
+		z, err := gzip.NewReader(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("gzip: %w", err)
+		}
+		return &acilmisOkuyucu{Reader: z, kapat: []func() error{z.Close, f.Close}}, nil
+	case "zstd":
+		return &acilmisOkuyucu{Reader: zstd.NewReader(f), kapat: []func() error{f.Close}}, nil
+	}
+	return f, nil
+}
+
+// acilmisOkuyucu Close'ta acici ve dosyayi sirayla kapatir
+type acilmisOkuyucu struct {
+	io.Reader
+	kapat []func() error
+}
+
+func (a *acilmisOkuyucu) Close() error {
+	var err error
+	for _, c := range a.kapat {
+		if e := c(); err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// openCikti tek dosyalik komutlarin ciktisini acar: fn bossa sablonla
+// output/ altinda, "-" ise stdout, degilse verilen yol. Donen fonksiyon
+// sikistiriciyi ve dosyayi kapatip manifest'e ekler.
+func openCikti(fn, title string, isCB bool, ext string) (io.Writer, string, func()) {
+	var f *os.File
+	var err error
+	switch fn {
+	case "":
+		f, fn, err = createFile(filepath.Join(cikti.outDir(), cikti.ad(title, isCB, "", ext, time.Now())))
+	case "-":
+		f = os.Stdout
+	default:
+		f, err = os.OpenFile(fn, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, filePerm)
+	}
+	if err != nil {
+		logx.Fatal("cannot open file", logx.F("dosya", fn), logx.Err(err))
+	}
+	w := newOzetYazici(f, cikti.sikistirmaOf(fn), ext)
+	return w, fn, func() {
+		if err := w.Close(); err != nil {
+			logx.Fatal("cannot write to file", logx.F("dosya", fn), logx.Err(err))
+		}
+		if f == os.Stdout {
+			return
+		}
+		if err := f.Close(); err != nil {
+			logx.Fatal("cannot close file", logx.F("dosya", fn), logx.Err(err))
+		}
+		kosu.ciktiEkle(w.kayit(fn, title+cbPrefix(isCB), isCB, true))
+	}
+}
+
+// endregion
+
+// ilCikti il bazli bolme acikken il bazli kapsamlarda her il icin ayri
+// dosya acar, kapaliyken tum illeri tek dosyaya yazar. Her dosya kendi
+// basligiyla baslar.
+type ilCikti struct {
+	ctx       context.Context
+	title     string
+	isCB      bool
+	bol       bool
+	sb        *SutunBilgi
+	w         io.Writer
+	pc        *PrintCtx
+	closeFile func()
+}
+
+func newIlCikti(ctx context.Context, title string, isCB bool, sb *SutunBilgi, ilBazli bool) *ilCikti {
+	o := &ilCikti{ctx: ctx, title: title, isCB: isCB, bol: ilBazli && cikti.ilBazli, sb: sb}
+	if !o.bol {
+		o.ac("")
+	}
+	return o
+}
+
+func (o *ilCikti) ac(il string) {
+	o.w, o.closeFile = openIlFile(o.ctx, o.title, o.isCB, il)
+	o.pc = o.sb.FprintHeader(o.w, o.title+cbPrefix(o.isCB), skippedColumnsFn(o.isCB))
+}
+
+// il siradaki ilin satirlarindan once cagrilir
+func (o *ilCikti) il(il string) {
+	if o.bol {
+		o.kapat()
+		o.ac(il)
+	}
+}
+
+// yaz satiri yazar ve sandigi tekrarlar icin deftere kaydeder
+func (o *ilCikti) yaz(k src.SandikKimligi, row map[string]any) {
+	defter.kaydet(k, o.title+cbPrefix(o.isCB))
+	o.pc.FprintRow(o.w, k.String(), row)
+}
+
+// kapat acik dosyayi kapatir; defer edilir
+func (o *ilCikti) kapat() {
+	if o.closeFile != nil {
+		o.closeFile()
+		o.closeFile = nil
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/secim/src/client"
+	"github.com/secim/src/logx"
+	"github.com/secim/src/progress"
+	"os"
+	"time"
+)
+
+// progressFlags -progress bayragini ekler; donen fonksiyon Parse'tan sonra
+// ilerleme panosunu out'a (stdout, stdout'a veri yaziliyorsa stderr) kurar
+// ve calistirir. Pano kapaliysa nil doner.
+// Terminalde her kapsam icin tek satir yerinde guncellenir, degilse
+// ayni satirlar periyodik olarak duz metin yazilir.
+func progressFlags(fs *flag.FlagSet) func(out *os.File) (*progress.Board, func()) {
+	mode := fs.String("progress", "auto", "ilerleme panosu: auto|tty|plain|off")
+	interval := fs.Duration("progress-interval", 0, "pano yenileme araligi (varsayilan terminalde 500ms, degilse 30s)")
+	return func(out *os.File) (*progress.Board, func()) {
+		var tty bool
+		switch *mode {
+		case "off":
+			return nil, func() {}
+		case "auto":
+			tty = progress.IsTerminal(out)
+		case "tty":
+			tty = true
+		case "plain":
+		default:
+			logx.Fatal("gecersiz ilerleme modu", logx.F("progress", *mode))
+		}
+		b := progress.New(out, tty)
+		b.Footer(panoOzeti())
+		// loglar panonun ustune yazilsin
+		if lg := logx.Default(); tty && lg.Writer() == os.Stderr {
+			logx.SetDefault(lg.WithOutput(b.Writer(os.Stderr)))
+		}
+		d := *interval
+		if d == 0 {
+			d = 30 * time.Second
+			if tty {
+				d = 500 * time.Millisecond
+			}
+		}
+		return b, b.Run(d)
+	}
+}
+
+// panoOzeti istek hizi, hata sayisi ve bellek satirini ureten fonksiyon doner
+func panoOzeti() func() string {
+	last, lastT := 0.0, time.Now()
+	return func() string {
+		req, retries := client.Totals()
+		now := time.Now()
+		rate := 0.0
+		if dt := now.Sub(lastT).Seconds(); dt > 0 {
+			rate = (req - last) / dt
+		}
+		last, lastT = req, now
+		return fmt.Sprintf("%.0f istek, %.1f istek/s, %.0f hata, bellek %s", req, rate, retries, memUsage())
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/secim/src"
+	"github.com/secim/src/logx"
+	"github.com/secim/src/registry"
+)
+
+// registryKomutu tum secim cevrelerinin ve yurt disinin basliklarini toplayip
+// ittifak / parti / bagimsiz referans tablosunu yazar. Catismalar programi durdurmaz.
+func registryKomutu(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("registry", flag.ExitOnError)
+	newClient := clientFlags(fs)
+	isCB := fs.Bool("cb", false, "cumhurbaskanligi basliklari (varsayilan mv)")
+	format := fs.String("format", "csv", "cikti formati: csv veya json")
+	out := fs.String("o", "", "cikti dosyasi (bos = -name-template ile output/ altinda, ornek registry<MV|CB>-<zaman>.<format>)")
+	setupOutput := outputFlags(fs)
+	parseFlags(fs, args)
+	setupOutput()
+	if *format != "csv" && *format != "json" {
+		logx.Fatal("gecersiz format", logx.F("format", *format))
+	}
+	c := newClient()
+	st := secimTurID(*isCB)
+
+	reg := registry.New()
+	cevreler := src.IlListesi(ctx, c, st, 0)
+	for cevIdx, cev := range cevreler {
+		logx.Info("Basliklar cekiliyor", logx.F("isCB", *isCB), logx.F("il", cev.IlADI),
+			logx.F("ilerleme", ilerleme(cevIdx, len(cevreler))))
+		reg.Add(cev.SecimCEVRESIID, src.SecimSonucBaslikListesi(ctx, c, cev, st))
+	}
+	reg.Add(registry.YurtdisiCevreID, src.YurtdisiSecimSonucBaslikListesi(ctx, c, st))
+	for _, cf := range reg.Conflicts() {
+		logx.Warn("baslik catismasi", logx.F("catisma", cf))
+	}
+
+	w, fn, closeOut := openCikti(*out, "registry", *isCB, *format)
+	var err error
+	if *format == "json" {
+		err = reg.WriteJSON(w)
+	} else {
+		err = reg.WriteCSV(w)
+	}
+	if err != nil {
+		logx.Fatal("cannot write to file", logx.F("dosya", fn), logx.Err(err))
+	}
+	closeOut()
+	logx.Info("Registry yazildi", logx.F("kayit", len(reg.Entries())),
+		logx.F("catisma", len(reg.Conflicts())), logx.F("dosya", fn))
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"github.com/secim/src/client"
+	"github.com/secim/src/logx"
+	"github.com/secim/src/schema"
+	"os"
+)
+
+// -schema-check fail iken kayitli semadan farkli yanit goren calismanin cikis kodu
+const exitSemaFarki = 3
+
+const (
+	semaKapali = "off"
+	semaUyar   = "warn"
+	semaHata   = "fail"
+)
+
+// semaAyari api yanitlarinin sema kontrolu; kokpit api'si belgesiz ve
+// secim gecelerinde anahtarlar degisebiliyor, json.Unmarshal bilinmeyen
+// alanlari sessizce atiyor
+var semaAyari struct {
+	kayit    *schema.Recorder
+	mod      string
+	dosya    string
+	guncelle bool
+	taban    bool
+}
+
+// semaFlags sema kontrolu bayraklarini ekler; donen fonksiyon istemciyi
+// kontrol acikken yanitlari kaydeden Recorder ile sarar.
+func semaFlags(fs *flag.FlagSet) func(client.Client) client.Client {
+	mod := fs.String("schema-check", semaKapali,
+		"api yanitlarini Go tipleriyle ve kayitli semayla karsilastir: off|warn|fail (fail: fark varsa cikis kodu 3)")
+	dosya := fs.String("schema-file", "schema.json", "karsilastirilacak sema dosyasi")
+	guncelle := fs.Bool("schema-update", false, "calisma bitince gorulen semayi -schema-file'a yaz")
+	return func(c client.Client) client.Client {
+		switch *mod {
+		case semaKapali, semaUyar, semaHata:
+		default:
+			logx.Fatal("gecersiz sema kontrolu", logx.F("schema-check", *mod))
+		}
+		if *mod == semaKapali && !*guncelle {
+			return c
+		}
+		r := schema.New(c)
+		semaAyari.kayit, semaAyari.mod, semaAyari.dosya, semaAyari.guncelle = r, *mod, *dosya, *guncelle
+		if *mod == semaKapali {
+			// sadece -schema-update: kaydet ama karsilastirma
+			return r
+		}
+		taban, err := schema.LoadSnapshot(*dosya)
+		switch {
+		case os.IsNotExist(err):
+			logx.Warn("Sema dosyasi yok; sadece bilinmeyen / eksik alanlar raporlanacak, olusturmak icin -schema-update",
+				logx.F("dosya", *dosya))
+		case err != nil:
+			logx.Fatal("cannot read schema file", logx.Err(err))
+		default:
+			semaAyari.taban = true
+			r.Compare(taban, func(d schema.Drift) {
+				// kaldirilan anahtarlar ancak calisma sonunda bilinir; semaRaporu'nda
+				logx.Warn("Api yanitinda yeni anahtar", logx.F("endpoint", d.Endpoint),
+					logx.F("eklenen", d.Added), logx.F("bilinmeyen", d.Unknown))
+			})
+		}
+		return r
+	}
+}
+
+// semaRaporu calisma sonunda farklari loglar ve manifest'e ekler; istenmisse
+// semayi gunceller. Kayitli semadan fark varsa true doner.
+func semaRaporu(tamam bool) bool {
+	r := semaAyari.kayit
+	if r == nil {
+		return false
+	}
+	fark := false
+	if semaAyari.mod != semaKapali {
+		rapor := r.Report()
+		for _, d := range rapor {
+			if !d.Empty() {
+				fark = true
+			}
+			logx.Warn("Sema farki", logx.F("endpoint", d.Endpoint), logx.F("eklenen", d.Added),
+				logx.F("kaldirilan", d.Removed), logx.F("bilinmeyen", d.Unknown), logx.F("eksik", d.Missing))
+		}
+		if len(rapor) == 0 {
+			logx.Info("Api yanitlari semayla uyumlu")
+		}
+		kosu.semaEkle(rapor)
+	}
+	// tamamlanmayan calismanin gordugu anahtarlar eksik olabilir
+	if semaAyari.guncelle && tamam {
+		if err := r.Snapshot().Save(semaAyari.dosya); err != nil {
+			logx.Error("cannot write schema file", logx.F("dosya", semaAyari.dosya), logx.Err(err))
+		} else {
+			logx.Info("Sema dosyasi yazildi", logx.F("dosya", semaAyari.dosya))
+		}
+	}
+	return fark
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/secim/src"
+	"github.com/secim/src/client"
+	"github.com/secim/src/logx"
+	"github.com/secim/src/progress"
+	"github.com/secim/src/registry"
+	"sync"
+)
+
+// region Scope
+
+// KapsamBilgisi bir kapsamin adlandirma ve cikti ayarlari
+type KapsamBilgisi struct {
+	// Ad dosya, metrik ve manifest'teki kapsam adi (ornek: sandiklar)
+	Ad string
+	// Etiket log mesajlarinin basi (ornek: "Yurt ici")
+	Etiket string
+	// BirimAlani birim adinin yazildigi log alani (il, ulke, gumruk)
+	BirimAlani string
+	// CacheAdi cache/ altindaki sutun bilgisi dosyasinin adi
+	CacheAdi string
+	// IlBazli -split-il acikken her birim ayri dosyaya yazilir
+	IlBazli bool
+}
+
+// Scope sandik sonuclarinin birim birim cekildigi bir kapsam. B kapsamin
+// gezdigi ust birimdir (il, ulke, gumruk kapisi); kapsamCek motoru
+// birimleri listeler, basliklari alir ve her birimin sonuclarini yazar.
+type Scope[B any] interface {
+	Bilgi() KapsamBilgisi
+	// Birimler gezilecek birimleri listeler
+	Birimler(ctx context.Context, c client.Client, secimTuru int) []B
+	// BirimAdi log, manifest ve il bazli dosya adi icin
+	BirimAdi(b B) string
+	// Basliklar birimlerin sutun basliklarini saglar
+	Basliklar(ctx context.Context, c client.Client, secimTuru int, birimler []B) BaslikKaynagi
+	// Params birimin SecimSandikSonucListesi sorgularini doner
+	Params(ctx context.Context, c client.Client, secimTuru int, b B) []src.SandikSonucQuery
+}
+
+// BaslikKaynagi bir kapsamin sutun basliklari
+type BaslikKaynagi interface {
+	// Adlar sutun bilgisinin ilk hali (ad -> baslik)
+	Adlar() map[string]src.SecimSonucBaslik
+	// Sutunlar i. birimin sonuclarindaki column name'leri basliklara esler
+	Sutunlar(i int) map[string]src.SecimSonucBaslik
+}
+
+// ortakBaslik tum birimler tek (yurt disi) baslik listesini kullanir
+type ortakBaslik struct {
+	adlar, sutunlar map[string]src.SecimSonucBaslik
+}
+
+func newOrtakBaslik(st int, l []src.SecimSonucBaslik) ortakBaslik {
+	reg := baslikKaydet(st, registry.YurtdisiCevreID, l)
+	return ortakBaslik{adlar: reg.Adlar(registry.YurtdisiCevreID), sutunlar: reg.Sutunlar(registry.YurtdisiCevreID)}
+}
+
+func (o ortakBaslik) Adlar() map[string]src.SecimSonucBaslik       { return o.adlar }
+func (o ortakBaslik) Sutunlar(int) map[string]src.SecimSonucBaslik { return o.sutunlar }
+
+// birimBasliklari her birimin (secim cevresinin) kendi baslik listesi var;
+// listeler kayitta, birimlerin cevre id'leri sirasiyla tutulur
+type birimBasliklari struct {
+	reg      *registry.Registry
+	cevreler []int
+}
+
+// Adlar tum cevrelerin basliklarinin union'ini verir
+func (b birimBasliklari) Adlar() map[string]src.SecimSonucBaslik {
+	return b.reg.Adlar(b.cevreler...)
+}
+
+// Sutunlar her cevrenin sonuclarini kendi column name'leriyle map'ler
+func (b birimBasliklari) Sutunlar(i int) map[string]src.SecimSonucBaslik {
+	return b.reg.Sutunlar(b.cevreler[i])
+}
+
+// kapsam sandik komutunun calistirdigi bir kapsam; Scope generic oldugu
+// icin listede tip parametresi olmadan tutulur
+type kapsam struct {
+	ad  string
+	cek func(ctx context.Context, c client.Client, wg *sync.WaitGroup, isCB bool, t *progress.Task)
+}
+
+func kapsamOf[B any](s Scope[B]) kapsam {
+	return kapsam{ad: s.Bilgi().Ad, cek: func(ctx context.Context, c client.Client, wg *sync.WaitGroup, isCB bool, t *progress.Task) {
+		kapsamCek(ctx, c, wg, isCB, t, s)
+	}}
+}
+
+// sandikKapsamlari sandik komutunun cb ve mv icin paralel cektigi kapsamlar
+var sandikKapsamlari = []kapsam{
+	kapsamOf[src.Il](yurticiKapsami),
+	kapsamOf[src.Ulke](disTemsKapsami{}),
+	kapsamOf[src.Il](cezaeviKapsami),
+	kapsamOf[src.Gumruk](gumrukKapsami{}),
+}
+
+// kapsamCek bir kapsamin sandik sonuclarini csv'ye yazar. Birimler iki kez
+// gezilir: once sutun bilgisini toplamak (onbellekte yoksa), sonra
+// siralanmis basliklarla yazmak icin.
+func kapsamCek[B any](ctx context.Context, c client.Client, wg *sync.WaitGroup, isCB bool, t *progress.Task, s Scope[B]) {
+	k := s.Bilgi()
+	defer wg.Done()
+	// o an islenen birim; iptal edilirse manifest'e basarisiz yazilir
+	var birim string
+	scope := k.Ad + cbPrefix(isCB)
+	kosu.kapsamEkle(scope)
+	defer kapsamKurtar(scope, &birim)
+	defer scopeRunning(scope)()
+	defer t.Finish()
+	lg := scopeLogger(k.Ad, isCB)
+	st := secimTurID(isCB)
+
+	// basliklari cek
+	lg.Info(k.Etiket+" sandik basliklari cekiliyor", logx.F("mem", memUsage()))
+	birimler := s.Birimler(ctx, c, st)
+	bas := s.Basliklar(ctx, c, st, birimler)
+
+	// gez birimleri sirayla dolasip her sonuc satirini kimligi ve birimin sutunlariyla satir'a verir
+	gez := func(islem string, birimBasi func(ad string),
+		satir func(src.SandikKimligi, map[string]src.SecimSonucBaslik, map[string]any)) {
+		for i, b := range birimler {
+			t.Inc()
+			birim = s.BirimAdi(b)
+			birimBasi(birim)
+			lg.Info(k.Etiket+" sandik verileri "+islem, logx.F(k.BirimAlani, birim),
+				logx.F("ilerleme", ilerleme(i, len(birimler))), logx.F("mem", memUsage()))
+			sutunlar := bas.Sutunlar(i)
+			for _, p := range s.Params(ctx, c, st, b) {
+				lg.Debug("Sandik sonuclari cekiliyor", logx.F(k.BirimAlani, birim), logx.F("params", p))
+				for j, sonuc := range src.SecimSandikSonucListesi(ctx, c, p) {
+					satir(p.Kimlik(sonuc, j), sutunlar, sonuc)
+				}
+			}
+		}
+	}
+
+	var sb SutunBilgi
+	cacheFilename := fmt.Sprintf("cache/__%s%d.cache", k.CacheAdi, st)
+	onbellekte := getSutunBilgiFromCache(cacheFilename, &sb)
+	// ilerleme toplami gezilecek turlar icin bastan verilir; tur basinda
+	// eklense ilk tur bitince %100 gorunup sonra yariya duserdi
+	turlar := 2
+	if onbellekte {
+		turlar = 1
+	}
+	t.AddTotal(turlar * len(birimler))
+	if onbellekte {
+		lg.Info(k.Etiket+" sandik sutun bilgileri onbellekten kullaniliyor", logx.F("mem", memUsage()))
+	} else {
+		sb = SutunBilgi{Names: bas.Adlar()}
+		lg.Info(k.Etiket+" sandik basliklari cekildi", logx.F("sutun", len(sb.Names)), logx.F("mem", memUsage()))
+		gez("cekiliyor", func(string) {}, func(_ src.SandikKimligi, sutunlar map[string]src.SecimSonucBaslik, sonuc map[string]any) {
+			// tum row'lari fetch et
+			sb.addRow(sutunlar, sonuc)
+		})
+		cacheSutunBilgi(cacheFilename, &sb)
+	}
+
+	// siralanmis basliklarla print
+	out := newIlCikti(ctx, k.Ad, isCB, &sb, k.IlBazli)
+	defer out.kapat()
+	gez("yaziliyor", out.il, func(k src.SandikKimligi, sutunlar map[string]src.SecimSonucBaslik, sonuc map[string]any) {
+		out.yaz(k, sb.addRow(sutunlar, sonuc))
+	})
+	lg.Info(k.Etiket+" sandik verileri dosyaya yazildi", logx.F("mem", memUsage()))
+}
+
+// endregion
+// region kapsamlar
+
+// ilKapsami secim cevrelerini (il) ve ilcelerini gezen kapsamlar: yurt ici
+// ve cezaevi sandiklari. Her cevrenin kendi baslik listesi var.
+type ilKapsami struct {
+	bilgi      KapsamBilgisi
+	sandikTuru int
+	params     func(src.Ilce, int) src.SandikSonucQuery
+}
+
+var (
+	yurticiKapsami = ilKapsami{
+		bilgi: KapsamBilgisi{Ad: "sandiklar", Etiket: "Yurt ici", BirimAlani: "il",
+			CacheAdi: "yurticiSandiklar", IlBazli: true},
+		params: src.IlceSonucParams,
+	}
+	cezaeviKapsami = ilKapsami{
+		bilgi: KapsamBilgisi{Ad: "cezaeviSandiklar", Etiket: "Cezaevi", BirimAlani: "il",
+			CacheAdi: "cezaeviSandiklar", IlBazli: true},
+		sandikTuru: src.SandikTuruCezaevi,
+		params:     src.CezaeviSonucParams,
+	}
+)
+
+func (s ilKapsami) Bilgi() KapsamBilgisi { return s.bilgi }
+
+func (s ilKapsami) Birimler(ctx context.Context, c client.Client, st int) []src.Il {
+	return src.IlListesi(ctx, c, st, s.sandikTuru)
+}
+
+func (s ilKapsami) BirimAdi(cev src.Il) string { return cev.IlADI }
+
+func (s ilKapsami) Basliklar(ctx context.Context, c client.Client, st int, cevreler []src.Il) BaslikKaynagi {
+	cevBas := birimBasliklari{reg: kosu.baslikKaydi(st), cevreler: make([]int, 0, len(cevreler))}
+	for _, cvr := range cevreler {
+		baslikKaydet(st, cvr.SecimCEVRESIID, src.SecimSonucBaslikListesi(ctx, c, cvr, st))
+		cevBas.cevreler = append(cevBas.cevreler, cvr.SecimCEVRESIID)
+	}
+	return cevBas
+}
+
+func (s ilKapsami) Params(ctx context.Context, c client.Client, st int, cev src.Il) []src.SandikSonucQuery {
+	var l []src.SandikSonucQuery
+	for _, ilce := range src.IlceListesi(ctx, c, cev, st, s.sandikTuru) {
+		l = append(l, s.params(ilce, st))
+	}
+	return l
+}
+
+// disTemsKapsami ulkeleri ve dis temsilciliklerini gezer; basliklar tum
+// yurt disi icin ortak
+type disTemsKapsami struct{}
+
+func (disTemsKapsami) Bilgi() KapsamBilgisi {
+	return KapsamBilgisi{Ad: "disTemsSandiklar", Etiket: "Yurt disi", BirimAlani: "ulke", CacheAdi: "disTemsSandiklar"}
+}
+
+func (disTemsKapsami) Birimler(ctx context.Context, c client.Client, _ int) []src.Ulke {
+	return src.UlkeListesi(ctx, c)
+}
+
+func (disTemsKapsami) BirimAdi(u src.Ulke) string { return u.UlkeADI }
+
+func (disTemsKapsami) Basliklar(ctx context.Context, c client.Client, st int, _ []src.Ulke) BaslikKaynagi {
+	return newOrtakBaslik(st, src.YurtdisiSecimSonucBaslikListesi(ctx, c, st))
+}
+
+func (disTemsKapsami) Params(ctx context.Context, c client.Client, st int, u src.Ulke) []src.SandikSonucQuery {
+	var l []src.SandikSonucQuery
+	for _, dt := range src.DisTemsilcilikListesi(ctx, c, u) {
+		l = append(l, src.DisTemsSonucParams(dt, st))
+	}
+	return l
+}
+
+// gumrukKapsami gumruk kapilarini gezer; basliklar yurt disiyla ortak
+type gumrukKapsami struct{}
+
+func (gumrukKapsami) Bilgi() KapsamBilgisi {
+	return KapsamBilgisi{Ad: "gumrukSandiklar", Etiket: "Gumruk", BirimAlani: "gumruk", CacheAdi: "gumrukSandiklar"}
+}
+
+func (gumrukKapsami) Birimler(ctx context.Context, c client.Client, _ int) []src.Gumruk {
+	return src.GumrukListesi(ctx, c)
+}
+
+func (gumrukKapsami) BirimAdi(g src.Gumruk) string { return g.GumrukADI }
+
+func (gumrukKapsami) Basliklar(ctx context.Context, c client.Client, st int, _ []src.Gumruk) BaslikKaynagi {
+	return newOrtakBaslik(st, src.YurtdisiSecimSonucBaslikListesi(ctx, c, st))
+}
+
+func (gumrukKapsami) Params(_ context.Context, _ client.Client, st int, g src.Gumruk) []src.SandikSonucQuery {
+	return []src.SandikSonucQuery{src.GumrukSonucParams(g, st)}
+}
+
+// endregion
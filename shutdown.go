@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"github.com/secim/src/logx"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// sinyalle yarida kesilen calismanin cikis kodu (128 + SIGINT)
+const exitInterrupted = 130
+
+// signalContext SIGINT / SIGTERM gelince iptal edilen kok context'i doner.
+// Ikinci sinyalde varsayilan davranisa donulur ve program hemen kapanir.
+func signalContext() context.Context {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+		logx.Warn("Sinyal alindi, kapatiliyor; hemen cikmak icin tekrar basin")
+	}()
+	return ctx
+}
+
+// kapanis komut bittikten sonra cagrilir; sema raporunu ve manifest'i yazar,
+// calisma yarida kesildiyse exitInterrupted ile cikar. Yarim dosyalar temp/'te kalir.
+func kapanis(ctx context.Context) {
+	semaFarki := semaRaporu(ctx.Err() == nil)
+	kosu.manifestYaz(ctx.Err() == nil)
+	if ctx.Err() != nil {
+		logx.Warn("Calisma yarida kesildi")
+		os.Exit(exitInterrupted)
+	}
+	if semaFarki && semaAyari.mod == semaHata {
+		logx.Error("Api semasi degismis", logx.F("dosya", semaAyari.dosya))
+		os.Exit(exitSemaFarki)
+	}
+}
+
+var fatalOnce sync.Once
+
+// fatalCikis logx.Fatal'in os.Exit yerine cagirdigi cikis. Calisma baslamissa
+// (istemci kurulmussa) kapanis gibi sema raporunu ve tamam=false manifest'i
+// yazar; o ana kadar biten dosyalar manifest'te, yarim dosyalar temp/'te kalir.
+// Ayni anda Fatal'a dusen diger goroutine'ler ilkinin cikisini bekler.
+func fatalCikis(code int) {
+	fatalOnce.Do(func() {
+		if kosu.basladi() {
+			semaRaporu(false)
+			kosu.manifestYaz(false)
+		}
+		os.Exit(code)
+	})
+}
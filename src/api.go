@@ -4,53 +4,77 @@ import (
 	"context"
 	"fmt"
 	"github.com/secim/src/client"
-	"log"
-	"net/url"
+	"github.com/secim/src/logx"
 	"sort"
 	"strings"
 	"time"
 )
 
 func Get[T any](
-	ctx context.Context, c client.Client, u string, m map[string]any,
+	ctx context.Context, c client.Client, u string, q Query,
 ) (t T, err error) {
 	if !strings.HasPrefix(u, "https://") {
 		u = "https://sspskokpit.ysk.gov.tr/api/ssps/" + strings.Trim(u, "/")
 	}
-	if len(m) != 0 {
-		vals := url.Values{}
-		for k, v := range m {
-			vals[k] = []string{fmt.Sprintf("%v", v)}
+	if q != nil {
+		if e := q.Encode(); e != "" {
+			u += "?" + e
 		}
-		u += "?" + vals.Encode()
 	}
 	err = c.Request(ctx, u, &t)
 	return
 }
 
-func MustGet[T any](c client.Client, endpoint string, m map[string]any) T {
-	ctx, cf := context.WithTimeout(context.Background(), time.Minute)
-	defer cf()
-	if m == nil {
-		m = make(map[string]any)
+// Canceled ctx iptal edildiginde MustGet'in panic degeri. MustGet kullanan
+// goroutine'ler defer'leri calistiktan sonra Recover ile bunu yakalar.
+type Canceled struct {
+	Err error
+}
+
+func (c Canceled) Error() string {
+	return "canceled: " + c.Err.Error()
+}
+
+// Recover goroutine'in ilk defer'i olarak kullanilir; Canceled panic'ini
+// yutar ve goroutine normal biter, diger panic'ler aynen devam eder.
+func Recover() {
+	if r := recover(); r != nil {
+		if _, ok := r.(Canceled); !ok {
+			panic(r)
+		}
 	}
-	t, err := Get[T](ctx, c, endpoint, m)
+}
+
+// istekSuresi bir MustGet cagrisinin yeniden denemeler dahil en uzun suresi
+const istekSuresi = time.Minute
+
+// MustGet istegi yapar; hata olursa programi kapatir. ctx iptal edilmisse
+// Canceled ile panic eder. Istemci gecici hatalarda yeniden dener; denemeler
+// istekSuresi ile sinirli, kalici hatalar (4xx, decode, pin) hemen doner.
+func MustGet[T any](ctx context.Context, c client.Client, endpoint string, q Query) T {
+	rctx, cf := context.WithTimeout(ctx, istekSuresi)
+	defer cf()
+	t, err := Get[T](rctx, c, endpoint, q)
 	if err != nil {
-		log.Fatalf("failed req to %s: %v\n", endpoint, err)
+		if ctx.Err() != nil {
+			panic(Canceled{Err: ctx.Err()})
+		}
+		logx.Fatal("failed request", logx.F("endpoint", endpoint), logx.F("params", q), logx.Err(err))
 	}
 	return t
 }
 
 const (
-	secimID = 60792
+	// SecimID 14 Mayis 2023 secimleri
+	SecimID = 60792
 	//	secimTuru = 9 // 8 == mv, 9 = cb
 )
 
 // region IlListesi
 
-func IlListesi(c client.Client, secimTuru, sandikTuru int) []Il {
-	return MustGet[[]Il](c, "getIlList", map[string]any{
-		"secimId": secimID, "secimTuru": secimTuru, "sandikTuru": sandikTuru, "yurtIciDisi": 1,
+func IlListesi(ctx context.Context, c client.Client, secimTuru, sandikTuru int) []Il {
+	return MustGet[[]Il](ctx, c, "getIlList", Params{
+		"secimId": SecimID, "secimTuru": secimTuru, "sandikTuru": sandikTuru, "yurtIciDisi": 1,
 	})
 }
 
@@ -74,9 +98,9 @@ type Il struct {
 // endregion
 // region IlceListesi
 
-func IlceListesi(c client.Client, i Il, secimTuru, sandikTuru int) []Ilce {
-	return MustGet[[]Ilce](c, "getIlceList", map[string]any{
-		"secimId": secimID, "secimTuru": secimTuru, "sandikTuru": sandikTuru, "yurtIciDisi": 1,
+func IlceListesi(ctx context.Context, c client.Client, i Il, secimTuru, sandikTuru int) []Ilce {
+	return MustGet[[]Ilce](ctx, c, "getIlceList", Params{
+		"secimId": SecimID, "secimTuru": secimTuru, "sandikTuru": sandikTuru, "yurtIciDisi": 1,
 		"ilId": i.IlID, "secimCevresiId": i.SecimCEVRESIID,
 	})
 }
@@ -107,9 +131,9 @@ type Ilce struct {
 // endregion
 // region MuhtarlikListesi
 
-func MuhtarlikListesi(c client.Client, i Ilce, secimTuru, sandikTuru int) []Muh {
-	return MustGet[[]Muh](c, "getMuhtarlikList", map[string]any{
-		"secimId": secimID, "secimTuru": secimTuru, "sandikTuru": sandikTuru, "yurtIciDisi": 1,
+func MuhtarlikListesi(ctx context.Context, c client.Client, i Ilce, secimTuru, sandikTuru int) []Muh {
+	return MustGet[[]Muh](ctx, c, "getMuhtarlikList", Params{
+		"secimId": SecimID, "secimTuru": secimTuru, "sandikTuru": sandikTuru, "yurtIciDisi": 1,
 		"ilceId": i.IlceID, "beldeId": i.BeldeID, "birimId": i.BirimID, "secimCevresiId": i.SecimCEVRESIID,
 	})
 }
@@ -147,9 +171,9 @@ type Muh struct {
 // endregion
 // region GumrukListesi
 
-func GumrukListesi(c client.Client) []Gumruk {
-	return MustGet[[]Gumruk](c, "getGumrukList", map[string]any{
-		"secimId": secimID,
+func GumrukListesi(ctx context.Context, c client.Client) []Gumruk {
+	return MustGet[[]Gumruk](ctx, c, "getGumrukList", Params{
+		"secimId": SecimID,
 	})
 }
 
@@ -170,9 +194,9 @@ type Gumruk struct {
 // endregion
 // region UlkeListesi
 
-func UlkeListesi(c client.Client) []Ulke {
-	return MustGet[[]Ulke](c, "getUlkeList", map[string]any{
-		"secimId": secimID,
+func UlkeListesi(ctx context.Context, c client.Client) []Ulke {
+	return MustGet[[]Ulke](ctx, c, "getUlkeList", Params{
+		"secimId": SecimID,
 	})
 }
 
@@ -188,9 +212,9 @@ type Ulke struct {
 // endregion
 // region DisTemsilcilikListesi
 
-func DisTemsilcilikListesi(c client.Client, u Ulke) []DisTemsilcilik {
-	return MustGet[[]DisTemsilcilik](c, "getDisTemsilcilikList", map[string]any{
-		"secimId": secimID, "ulkeId": u.UlkeID,
+func DisTemsilcilikListesi(ctx context.Context, c client.Client, u Ulke) []DisTemsilcilik {
+	return MustGet[[]DisTemsilcilik](ctx, c, "getDisTemsilcilikList", Params{
+		"secimId": SecimID, "ulkeId": u.UlkeID,
 	})
 }
 
@@ -236,9 +260,9 @@ type DisTemsilcilik struct {
 //		&secimCevresiId=404520
 //		&sandikId=
 
-func SecimSonucListesi(c client.Client, i Ilce, secimTuru int) []SecimSonuc {
-	return MustGet[[]SecimSonuc](c, "getSecimSonucList", map[string]any{
-		"secimId": secimID, "secimTuru": secimTuru, "sandikTuru": 0, "yurtIciDisi": 1, "sandikId": "",
+func SecimSonucListesi(ctx context.Context, c client.Client, i Ilce, secimTuru int) []SecimSonuc {
+	return MustGet[[]SecimSonuc](ctx, c, "getSecimSonucList", Params{
+		"secimId": SecimID, "secimTuru": secimTuru, "sandikTuru": 0, "yurtIciDisi": 1, "sandikId": "",
 		"ilId": i.IlID, "ilceId": i.IlceID, "beldeId": i.BeldeID, "birimId": i.BirimID, "muhtarlikId": "",
 		"cezaeviId": "", "sandikNoIlk": "", "sandikNoSon": "", "ulkeId": "", "disTemsilcilikId": "",
 		"gumrukId": "", "sandikRumuzIlk": "", "sandikRumuzSon": "", "secimCevresiId": i.SecimCEVRESIID,
@@ -272,12 +296,10 @@ type SecimSonuc struct {
 // endregion
 // region SecimSandikSonucListesi
 
-func GumrukSonucParams(g Gumruk, secimTuru int) map[string]any {
-	return map[string]any{
-		"secimId": secimID, "secimTuru": secimTuru, "sandikTuru": 1, "yurtIciDisi": 2, "ulkeId": "",
-		"gumrukId": g.GumrukID, "ilId": "", "ilceId": g.IlceID, "beldeId": "", "birimId": "",
-		"muhtarlikId": "", "cezaeviId": "", "sandikNoIlk": "", "sandikNoSon": "", "disTemsilcilikId": "",
-		"sandikRumuzIlk": "", "sandikRumuzSon": "", "secimCevresiId": "", "sandikId": "",
+func GumrukSonucParams(g Gumruk, secimTuru int) SandikSonucQuery {
+	return SandikSonucQuery{
+		SecimID: SecimID, SecimTuru: secimTuru, SandikTuru: SandikTuruGumruk, YurtIciDisi: YurtDisi,
+		IlceID: g.IlceID, GumrukID: g.GumrukID,
 	}
 }
 
@@ -303,12 +325,17 @@ func GumrukSonucParams(g Gumruk, secimTuru int) map[string]any {
 //		&secimCevresiId=
 //		&sandikId=
 
-func CezaeviSonucParams(i Ilce, secimTuru int) map[string]any {
-	return map[string]any{
-		"secimId": secimID, "secimTuru": secimTuru, "sandikTuru": 2, "yurtIciDisi": 1, "ulkeId": "",
-		"disTemsilcilikId": "", "ilId": i.IlID, "ilceId": i.IlceID, "beldeId": i.BeldeID, "birimId": i.BirimID,
-		"muhtarlikId": "", "cezaeviId": "", "sandikNoIlk": "", "sandikNoSon": "", "gumrukId": "",
-		"sandikRumuzIlk": "", "sandikRumuzSon": "", "secimCevresiId": i.SecimCEVRESIID, "sandikId": "",
+// MuhtarlikSonucParams IlceSonucParams'in tek muhtarlikla sinirli hali
+func MuhtarlikSonucParams(i Ilce, m Muh, secimTuru int) SandikSonucQuery {
+	q := IlceSonucParams(i, secimTuru)
+	q.MuhtarlikID = m.MuhtarlikID
+	return q
+}
+
+func CezaeviSonucParams(i Ilce, secimTuru int) SandikSonucQuery {
+	return SandikSonucQuery{
+		SecimID: SecimID, SecimTuru: secimTuru, SandikTuru: SandikTuruCezaevi, YurtIciDisi: YurtIci,
+		IlID: i.IlID, IlceID: i.IlceID, BeldeID: i.BeldeID, BirimID: i.BirimID, SecimCevresiID: i.SecimCEVRESIID,
 	}
 }
 
@@ -334,12 +361,10 @@ func CezaeviSonucParams(i Ilce, secimTuru int) map[string]any {
 //		&secimCevresiId=404480
 //		&sandikId=
 
-func DisTemsSonucParams(d DisTemsilcilik, secimTuru int) map[string]any {
-	return map[string]any{
-		"secimId": secimID, "secimTuru": secimTuru, "sandikTuru": 3, "yurtIciDisi": 2, "ulkeId": d.UlkeID,
-		"disTemsilcilikId": d.DisTEMSILCILIKID, "ilId": "", "ilceId": "", "beldeId": "", "birimId": "",
-		"muhtarlikId": "", "cezaeviId": "", "sandikNoIlk": "", "sandikNoSon": "", "gumrukId": "",
-		"sandikRumuzIlk": "", "sandikRumuzSon": "", "secimCevresiId": "", "sandikId": "",
+func DisTemsSonucParams(d DisTemsilcilik, secimTuru int) SandikSonucQuery {
+	return SandikSonucQuery{
+		SecimID: SecimID, SecimTuru: secimTuru, SandikTuru: SandikTuruDisTemsilcilik, YurtIciDisi: YurtDisi,
+		UlkeID: d.UlkeID, DisTemsilcilikID: d.DisTEMSILCILIKID,
 	}
 }
 
@@ -365,12 +390,10 @@ func DisTemsSonucParams(d DisTemsilcilik, secimTuru int) map[string]any {
 //		&secimCevresiId=
 //		&sandikId=
 
-func IlceSonucParams(i Ilce, secimTuru int) map[string]any {
-	return map[string]any{
-		"secimId": secimID, "secimTuru": secimTuru, "sandikTuru": 0, "yurtIciDisi": 1, "sandikId": "",
-		"ilId": i.IlID, "ilceId": i.IlceID, "beldeId": i.BeldeID, "birimId": i.BirimID, "muhtarlikId": "",
-		"cezaeviId": "", "sandikNoIlk": "", "sandikNoSon": "", "ulkeId": "", "disTemsilcilikId": "",
-		"gumrukId": "", "sandikRumuzIlk": "", "sandikRumuzSon": "", "secimCevresiId": i.SecimCEVRESIID,
+func IlceSonucParams(i Ilce, secimTuru int) SandikSonucQuery {
+	return SandikSonucQuery{
+		SecimID: SecimID, SecimTuru: secimTuru, SandikTuru: SandikTuruIlce, YurtIciDisi: YurtIci,
+		IlID: i.IlID, IlceID: i.IlceID, BeldeID: i.BeldeID, BirimID: i.BirimID, SecimCevresiID: i.SecimCEVRESIID,
 	}
 }
 
@@ -396,8 +419,19 @@ func IlceSonucParams(i Ilce, secimTuru int) map[string]any {
 //		&secimCevresiId=404520
 //		&sandikId=
 
-func SecimSandikSonucListesi(c client.Client, p map[string]any) []map[string]any {
-	return MustGet[[]map[string]any](c, "getSecimSandikSonucList", p)
+// SecimSandikSonucListesi satirlari api'den geldigi gibi doner; null alanlar
+// nil kalir. Tipli hali SandikSonuclari.
+func SecimSandikSonucListesi(ctx context.Context, c client.Client, q SandikSonucQuery) []map[string]any {
+	mustValidate(q)
+	return MustGet[[]map[string]any](ctx, c, "getSecimSandikSonucList", q)
+}
+
+// mustValidate gecersiz sorgu gondermek yerine programi kapatir; api
+// eksik parametreyle hata vermeden bos liste donebiliyor
+func mustValidate(q SandikSonucQuery) {
+	if err := q.Validate(); err != nil {
+		logx.Fatal("invalid query", logx.F("params", q), logx.Err(err))
+	}
 }
 
 // endregion
@@ -412,9 +446,9 @@ func SecimSandikSonucListesi(c client.Client, p map[string]any) []map[string]any
 //		&ilId=6
 //		&bagimsiz=1
 
-func SecimSonucBaslikListesi(c client.Client, i Il, secimTuru int) []SecimSonucBaslik {
-	return MustGet[[]SecimSonucBaslik](c, "getSandikSecimSonucBaslikList", map[string]any{
-		"secimId": secimID, "secimTuru": secimTuru, "yurtIciDisi": 1,
+func SecimSonucBaslikListesi(ctx context.Context, c client.Client, i Il, secimTuru int) []SecimSonucBaslik {
+	return MustGet[[]SecimSonucBaslik](ctx, c, "getSandikSecimSonucBaslikList", Params{
+		"secimId": SecimID, "secimTuru": secimTuru, "yurtIciDisi": 1,
 		"secimCevresiId": i.SecimCEVRESIID, "ilId": i.IlID, "bagimsiz": 1,
 	})
 }
@@ -428,9 +462,9 @@ func SecimSonucBaslikListesi(c client.Client, i Il, secimTuru int) []SecimSonucB
 //		&ilId=
 //		&bagimsiz=1
 
-func YurtdisiSecimSonucBaslikListesi(c client.Client, secimTuru int) []SecimSonucBaslik {
-	return MustGet[[]SecimSonucBaslik](c, "getSandikSecimSonucBaslikList", map[string]any{
-		"secimId": secimID, "secimTuru": secimTuru, "yurtIciDisi": 2,
+func YurtdisiSecimSonucBaslikListesi(ctx context.Context, c client.Client, secimTuru int) []SecimSonucBaslik {
+	return MustGet[[]SecimSonucBaslik](ctx, c, "getSandikSecimSonucBaslikList", Params{
+		"secimId": SecimID, "secimTuru": secimTuru, "yurtIciDisi": 2,
 		"secimCevresiId": "", "ilId": "", "bagimsiz": 1,
 	})
 }
@@ -444,18 +478,18 @@ type SecimSonucBaslik struct {
 // endregion
 // region MVSonucListesi
 
-func GenelMVSonuclar(c client.Client) MVSonuc {
-	return MustGet[MVSonuc](c,
+func GenelMVSonuclar(ctx context.Context, c client.Client) MVSonuc {
+	return MustGet[MVSonuc](ctx, c,
 		"https://sspskokpit.ysk.gov.tr/api/milletvekili/indexpagedata",
-		map[string]any{"cacheSlayer": time.Now().UnixMilli()})
+		Params{"cacheSlayer": time.Now().UnixMilli()})
 }
 
 // https://sspskokpit.ysk.gov.tr/api/milletvekili/birim/SECIM_CEVRESI/404520?cacheSlayer=1684072407851
 
-func CevreMVSonuclar(c client.Client, cevreID int) DVOData {
-	dd := MustGet[DVOData](c, fmt.Sprintf(
+func CevreMVSonuclar(ctx context.Context, c client.Client, cevreID int) DVOData {
+	dd := MustGet[DVOData](ctx, c, fmt.Sprintf(
 		"https://sspskokpit.ysk.gov.tr/api/milletvekili/birim/SECIM_CEVRESI/%d", cevreID,
-	), map[string]any{"cacheSlayer": time.Now().UnixMilli()})
+	), Params{"cacheSlayer": time.Now().UnixMilli()})
 	sort.Slice(dd.PartiDVOs, func(i, j int) bool {
 		return dd.PartiDVOs[i].PartiSira < dd.PartiDVOs[j].PartiSira
 	})
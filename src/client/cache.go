@@ -0,0 +1,260 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// region DiskCache
+
+// CacheRule sets how long responses for URL paths containing Match are cached.
+// The first matching rule wins; responses matching no rule are not cached.
+type CacheRule struct {
+	Match string
+	TTL   time.Duration
+}
+
+// DefaultCacheRules keeps hierarchy lists for days and results for minutes.
+var DefaultCacheRules = []CacheRule{
+	{Match: "getIlList", TTL: 72 * time.Hour},
+	{Match: "getIlceList", TTL: 72 * time.Hour},
+	{Match: "getMuhtarlikList", TTL: 72 * time.Hour},
+	{Match: "getUlkeList", TTL: 72 * time.Hour},
+	{Match: "getDisTemsilcilikList", TTL: 72 * time.Hour},
+	{Match: "getGumrukList", TTL: 72 * time.Hour},
+	{Match: "getSandikSecimSonucBaslikList", TTL: 24 * time.Hour},
+	{Match: "getSecimSandikSonucList", TTL: 5 * time.Minute},
+	{Match: "getSecimSonucList", TTL: 5 * time.Minute},
+	{Match: "milletvekili", TTL: time.Minute},
+}
+
+// CacheEntry is the metadata of a cached response. The body is stored in a
+// separate file next to it.
+type CacheEntry struct {
+	Key       string    `json:"key"`
+	URL       string    `json:"url"`
+	Status    int       `json:"status"`
+	FetchedAt time.Time `json:"fetchedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	SHA256    string    `json:"sha256"`
+	Size      int       `json:"size"`
+}
+
+func (e CacheEntry) Expired(now time.Time) bool {
+	return !now.Before(e.ExpiresAt)
+}
+
+// DiskCache is a Doer that stores successful GET responses on disk, keyed by
+// the normalized request URL.
+type DiskCache struct {
+	Dir   string
+	Rules []CacheRule
+	Next  Doer
+	Now   func() time.Time
+}
+
+func NewDiskCache(dir string, next Doer, rules []CacheRule) *DiskCache {
+	return &DiskCache{Dir: dir, Rules: rules, Next: next, Now: time.Now}
+}
+
+// NormalizeURL lowercases the host, sorts the query and drops the per-request
+// cacheSlayer parameter so equivalent requests share a cache key.
+func NormalizeURL(u *url.URL) string {
+	q := u.Query()
+	q.Del("cacheSlayer")
+	n := url.URL{Scheme: strings.ToLower(u.Scheme), Host: strings.ToLower(u.Host), Path: u.Path, RawQuery: q.Encode()}
+	return n.String()
+}
+
+func cacheKey(normURL string) string {
+	h := sha256.Sum256([]byte(normURL))
+	return hex.EncodeToString(h[:])
+}
+
+func (d *DiskCache) ttl(u *url.URL) time.Duration {
+	for _, r := range d.Rules {
+		if strings.Contains(u.Path, r.Match) {
+			return r.TTL
+		}
+	}
+	return 0
+}
+
+func (d *DiskCache) metaPath(key string) string { return filepath.Join(d.Dir, key+".json") }
+func (d *DiskCache) bodyPath(key string) string { return filepath.Join(d.Dir, key+".body") }
+
+func (d *DiskCache) Do(r *http.Request) (*http.Response, error) {
+	ttl := d.ttl(r.URL)
+	if r.Method != http.MethodGet || ttl <= 0 {
+		return d.Next.Do(r)
+	}
+	normURL := NormalizeURL(r.URL)
+	key := cacheKey(normURL)
+	if e, body, err := d.load(key); err == nil && !e.Expired(d.Now()) {
+		return cachedResponse(r, e, body), nil
+	}
+	rs, err := d.Next.Do(r)
+	if err != nil || rs.StatusCode != http.StatusOK {
+		return rs, err
+	}
+	body, err := io.ReadAll(rs.Body)
+	_ = rs.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	rs.Body = io.NopCloser(bytes.NewReader(body))
+	now := d.Now()
+	sum := sha256.Sum256(body)
+	e := CacheEntry{
+		Key: key, URL: normURL, Status: rs.StatusCode, FetchedAt: now, ExpiresAt: now.Add(ttl),
+		SHA256: hex.EncodeToString(sum[:]), Size: len(body),
+	}
+	// the response is still usable if storing fails; the next request retries
+	_ = d.store(e, body)
+	return rs, nil
+}
+
+func cachedResponse(r *http.Request, e CacheEntry, body []byte) *http.Response {
+	return &http.Response{
+		Status: fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)), StatusCode: e.Status,
+		Proto: "HTTP/1.1", ProtoMajor: 1, ProtoMinor: 1,
+		Header:        http.Header{"X-Cache": []string{"HIT"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)), Request: r,
+	}
+}
+
+// load reads the metadata and the body; a body whose hash does not match is
+// reported as corrupt.
+func (d *DiskCache) load(key string) (e CacheEntry, body []byte, err error) {
+	if e, err = d.loadMeta(key); err != nil {
+		return
+	}
+	if body, err = os.ReadFile(d.bodyPath(key)); err != nil {
+		return
+	}
+	if sum := sha256.Sum256(body); hex.EncodeToString(sum[:]) != e.SHA256 {
+		err = fmt.Errorf("cache entry %s: content hash mismatch", key)
+	}
+	return
+}
+
+func (d *DiskCache) loadMeta(key string) (e CacheEntry, err error) {
+	b, err := os.ReadFile(d.metaPath(key))
+	if err == nil {
+		err = json.Unmarshal(b, &e)
+	}
+	return
+}
+
+func (d *DiskCache) store(e CacheEntry, body []byte) error {
+	if err := os.MkdirAll(d.Dir, 0o755); err != nil {
+		return err
+	}
+	meta, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	// body first, then metadata; a torn write is caught by the hash check
+	if err = writeFileAtomic(d.bodyPath(e.Key), body); err != nil {
+		return err
+	}
+	return writeFileAtomic(d.metaPath(e.Key), meta)
+}
+
+// writeFileAtomic writes b to a uniquely named temporary file in fn's
+// directory and renames it over fn, so concurrent writers of the same entry
+// never share a temporary file and readers see either version whole.
+func writeFileAtomic(fn string, b []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(fn), filepath.Base(fn)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(b)
+	if er := f.Close(); err == nil {
+		err = er
+	}
+	if err == nil {
+		// CreateTemp creates the file 0600
+		err = os.Chmod(tmp, 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, fn)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+	}
+	return err
+}
+
+// Entries returns all cache entries sorted by URL.
+func (d *DiskCache) Entries() ([]CacheEntry, error) {
+	paths, err := filepath.Glob(filepath.Join(d.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	l := make([]CacheEntry, 0, len(paths))
+	for _, p := range paths {
+		if e, err := d.loadMeta(strings.TrimSuffix(filepath.Base(p), ".json")); err == nil {
+			l = append(l, e)
+		}
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].URL < l[j].URL })
+	return l, nil
+}
+
+// Verify checks the content hash of the entry's body.
+func (d *DiskCache) Verify(e CacheEntry) error {
+	_, _, err := d.load(e.Key)
+	return err
+}
+
+// Prune removes expired and corrupt entries.
+func (d *DiskCache) Prune() (int, error) {
+	now := d.Now()
+	return d.removeIf(func(e CacheEntry) bool {
+		return e.Expired(now) || d.Verify(e) != nil
+	})
+}
+
+// Invalidate removes entries whose URL contains match. An empty match clears
+// the whole cache.
+func (d *DiskCache) Invalidate(match string) (int, error) {
+	return d.removeIf(func(e CacheEntry) bool {
+		return strings.Contains(e.URL, match)
+	})
+}
+
+func (d *DiskCache) removeIf(fn func(CacheEntry) bool) (n int, err error) {
+	l, err := d.Entries()
+	if err != nil {
+		return
+	}
+	for _, e := range l {
+		if !fn(e) {
+			continue
+		}
+		if er := os.Remove(d.metaPath(e.Key)); er != nil && !os.IsNotExist(er) {
+			return n, er
+		}
+		if er := os.Remove(d.bodyPath(e.Key)); er != nil && !os.IsNotExist(er) {
+			return n, er
+		}
+		n++
+	}
+	return
+}
+
+// endregion
@@ -0,0 +1,189 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// cacheFixture returns a cache in a temp dir with a settable clock, in front
+// of a server answering with the request count.
+func cacheFixture(t *testing.T) (*DiskCache, *time.Time, *httptest.Server, *int32) {
+	t.Helper()
+	var n int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "[%d]", atomic.AddInt32(&n, 1))
+	}))
+	t.Cleanup(s.Close)
+	now := time.Date(2023, 5, 14, 20, 0, 0, 0, time.UTC)
+	d := NewDiskCache(t.TempDir(), http.DefaultClient, []CacheRule{
+		{Match: "getIlList", TTL: time.Hour},
+		{Match: "getSecimSandikSonucList", TTL: time.Minute},
+	})
+	d.Now = func() time.Time { return now }
+	return d, &now, s, &n
+}
+
+func get(t *testing.T, d *DiskCache, u string) string {
+	t.Helper()
+	r, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := d.Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rs.Body.Close()
+	b, err := io.ReadAll(rs.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func mustParse(t *testing.T, u string) *url.URL {
+	t.Helper()
+	p, err := url.Parse(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestDiskCacheTTL(t *testing.T) {
+	d, now, s, n := cacheFixture(t)
+	il := s.URL + "/api/getIlList?secimId=1&cacheSlayer=1"
+	if got := get(t, d, il); got != "[1]" {
+		t.Fatalf("got %s", got)
+	}
+	// cacheSlayer is not part of the key
+	if got := get(t, d, s.URL+"/api/getIlList?cacheSlayer=2&secimId=1"); got != "[1]" {
+		t.Errorf("cached response not used: %s", got)
+	}
+	// paths matching no rule are not cached
+	get(t, d, s.URL+"/api/getSecimSonucList")
+	get(t, d, s.URL+"/api/getSecimSonucList")
+	if *n != 3 {
+		t.Errorf("got %d requests, want 3", *n)
+	}
+
+	*now = now.Add(59 * time.Minute)
+	if got := get(t, d, il); got != "[1]" {
+		t.Errorf("entry expired early: %s", got)
+	}
+	*now = now.Add(time.Minute)
+	if got := get(t, d, il); got != "[4]" {
+		t.Errorf("expired entry used: %s", got)
+	}
+	l, err := d.Entries()
+	if err != nil || len(l) != 1 {
+		t.Fatalf("entries = %v, %v", l, err)
+	}
+	if want := now.Add(time.Hour); !l[0].ExpiresAt.Equal(want) || l[0].Size != 3 {
+		t.Errorf("entry = %+v", l[0])
+	}
+}
+
+func TestDiskCacheVerify(t *testing.T) {
+	d, _, s, n := cacheFixture(t)
+	il := s.URL + "/api/getIlList?secimId=1"
+	get(t, d, il)
+	l, err := d.Entries()
+	if err != nil || len(l) != 1 {
+		t.Fatalf("entries = %v, %v", l, err)
+	}
+	if err := d.Verify(l[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(d.bodyPath(l[0].Key), []byte("[9]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Verify(l[0]); err == nil {
+		t.Error("corrupt body verified")
+	}
+	// a corrupt entry is fetched again and overwritten
+	if got := get(t, d, il); got != "[2]" || *n != 2 {
+		t.Errorf("got %s after %d requests", got, *n)
+	}
+	if err := d.Verify(l[0]); err != nil {
+		t.Errorf("entry not rewritten: %v", err)
+	}
+}
+
+func TestDiskCachePruneInvalidate(t *testing.T) {
+	d, now, s, _ := cacheFixture(t)
+	get(t, d, s.URL+"/api/getIlList?secimId=1")
+	get(t, d, s.URL+"/api/getIlList?secimId=2")
+	get(t, d, s.URL+"/api/getSecimSandikSonucList?ilceId=1")
+	get(t, d, s.URL+"/api/getSecimSandikSonucList?ilceId=2")
+	l, _ := d.Entries()
+	if len(l) != 4 {
+		t.Fatalf("got %d entries", len(l))
+	}
+	// corrupt one hierarchy entry; the result entries expire
+	var bozuk CacheEntry
+	for _, e := range l {
+		if e.URL == NormalizeURL(mustParse(t, s.URL+"/api/getIlList?secimId=2")) {
+			bozuk = e
+		}
+	}
+	if err := os.WriteFile(d.bodyPath(bozuk.Key), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	*now = now.Add(2 * time.Minute)
+	if n, err := d.Prune(); err != nil || n != 3 {
+		t.Errorf("Prune = %d, %v; want 3", n, err)
+	}
+	if l, _ := d.Entries(); len(l) != 1 || l[0].URL != NormalizeURL(mustParse(t, s.URL+"/api/getIlList?secimId=1")) {
+		t.Errorf("entries after prune = %v", l)
+	}
+
+	get(t, d, s.URL+"/api/getSecimSandikSonucList?ilceId=1")
+	if n, err := d.Invalidate("getSecimSandikSonucList"); err != nil || n != 1 {
+		t.Errorf("Invalidate = %d, %v; want 1", n, err)
+	}
+	if n, err := d.Invalidate(""); err != nil || n != 1 {
+		t.Errorf("Invalidate all = %d, %v; want 1", n, err)
+	}
+	files, _ := filepath.Glob(filepath.Join(d.Dir, "*"))
+	if len(files) != 0 {
+		t.Errorf("files left: %v", files)
+	}
+}
+
+func TestDiskCacheConcurrentStore(t *testing.T) {
+	d, _, s, _ := cacheFixture(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, _ := http.NewRequest(http.MethodGet, s.URL+"/api/getIlList?secimId=1", nil)
+			if rs, err := d.Do(r); err == nil {
+				_ = rs.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	l, err := d.Entries()
+	if err != nil || len(l) != 1 {
+		t.Fatalf("entries = %v, %v", l, err)
+	}
+	// body and metadata may come from different writers; the hash check
+	// catches that, so only leftovers and modes are checked here
+	if tmp, _ := filepath.Glob(filepath.Join(d.Dir, "*.tmp")); len(tmp) != 0 {
+		t.Errorf("temporary files left: %v", tmp)
+	}
+	if fi, err := os.Stat(d.bodyPath(l[0].Key)); err != nil || fi.Mode().Perm() != 0o644 {
+		t.Errorf("body file mode: %v, %v", fi, err)
+	}
+}
@@ -1,14 +1,19 @@
 package client
 
 import (
+	"bytes"
 	"context"
-	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/secim/src/logx"
 	"io"
-	"log"
 	"net"
 	"net/http"
+	"net/url"
 	"time"
+	"unicode"
 )
 
 // region Client
@@ -30,11 +35,23 @@ type cli struct {
 }
 
 func (c *cli) Request(ctx context.Context, uri string, resp any) (err error) {
-	// log.Printf("[REQ] %s\n", uri)
+	logx.Debug("request", logx.F("url", uri))
 	err = c.reqLoop(ctx, uri, resp)
-	for err != nil {
-		log.Printf("req fail: %v\n", err)
-		time.Sleep(1000 * time.Millisecond)
+	for attempt := 1; err != nil; attempt++ {
+		if ctx.Err() != nil {
+			// keep the last failure; "context deadline exceeded" alone hides it
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		}
+		if IsPermanent(err) {
+			return err
+		}
+		logx.Warn("request failed, retrying", logx.F("url", uri), logx.F("attempt", attempt), logx.Err(err))
+		retriesTotal.Inc(endpointOf(uri))
+		select {
+		case <-time.After(1000 * time.Millisecond):
+		case <-ctx.Done():
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		}
 		err = c.reqLoop(ctx, uri, resp)
 	}
 	return
@@ -47,20 +64,77 @@ func (c *cli) reqLoop(ctx context.Context, uri string, resp any) error {
 	}
 	rs, err := c.c.Do(rq)
 	if err != nil {
-		return err
+		return transportError(err)
 	}
 	defer func() { _ = rs.Body.Close() }()
-	var buf []byte
-	if sc := rs.StatusCode; sc == http.StatusOK {
-		if buf, err = io.ReadAll(rs.Body); err == nil {
-			if err == nil {
-				err = json.Unmarshal(buf, resp)
-			}
+	if rs.StatusCode != http.StatusOK {
+		// an error page must not look like an empty result
+		err := fmt.Errorf("unexpected status: %s", rs.Status)
+		if !retryableStatus(rs.StatusCode) {
+			return Permanent(err)
 		}
+		return err
+	}
+	buf, err := io.ReadAll(rs.Body)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(buf, resp); err != nil && !truncated(err, buf) {
+		// the body arrived whole but does not fit resp; the next answer will be the same
+		return Permanent(err)
+	}
+	return err
+}
+
+// truncated reports whether a decode error is an empty or cut off body, which
+// a dropped connection can cause and a retry can fix.
+func truncated(err error, buf []byte) bool {
+	var se *json.SyntaxError
+	return errors.As(err, &se) && se.Offset >= int64(len(bytes.TrimRightFunc(buf, unicode.IsSpace)))
+}
+
+// transportError marks certificate and pin failures permanent; the server
+// presents the same certificate on every attempt.
+func transportError(err error) error {
+	var (
+		ua x509.UnknownAuthorityError
+		he x509.HostnameError
+		ci x509.CertificateInvalidError
+	)
+	if errors.Is(err, ErrPinMismatch) || errors.As(err, &ua) || errors.As(err, &he) || errors.As(err, &ci) {
+		return Permanent(err)
 	}
 	return err
 }
 
+// retryableStatus reports whether a non-200 status may go away on its own:
+// server errors and rate limiting. Other 4xx answers will not change.
+func retryableStatus(code int) bool {
+	return code >= 500 || code == http.StatusTooManyRequests
+}
+
+// permanentError marks a failure that retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so that Request returns it without retrying.
+func Permanent(err error) error {
+	if err == nil || IsPermanent(err) {
+		return err
+	}
+	return permanentError{err: err}
+}
+
+// IsPermanent reports whether err, or an error it wraps, was marked Permanent.
+func IsPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
+
 // endregion
 // region Doer
 
@@ -70,20 +144,93 @@ type Doer interface {
 	Do(r *http.Request) (*http.Response, error)
 }
 
-func NewHTTPClient() Doer {
+// Options configures the transport built by NewHTTPClient.
+type Options struct {
+	TLS TLSOptions
+	// Proxy is an http, https or socks5 proxy URL. When empty the
+	// HTTP_PROXY / HTTPS_PROXY / NO_PROXY environment variables are used.
+	Proxy string
+	// Timeout bounds the whole request including reading the body.
+	Timeout time.Duration
+	// HeaderTimeout bounds the wait for the response headers.
+	HeaderTimeout   time.Duration
+	MaxConnsPerHost int
+	MaxIdleConns    int
+	UserAgent       string
+	// Headers are added to every request.
+	Headers http.Header
+	HTTP2   bool
+}
+
+// DefaultOptions returns the settings the fetcher has always used.
+func DefaultOptions() Options {
+	return Options{
+		Timeout:       10 * time.Second,
+		HeaderTimeout: 10 * time.Second,
+		MaxIdleConns:  10,
+		UserAgent:     "kokpitFetch",
+	}
+}
+
+func NewHTTPClient(o Options) (Doer, error) {
+	tc, err := o.TLS.Config()
+	if err != nil {
+		return nil, err
+	}
+	proxy := http.ProxyFromEnvironment
+	if o.Proxy != "" {
+		u, err := url.Parse(o.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url: %w", err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme: %q", u.Scheme)
+		}
+		proxy = http.ProxyURL(u)
+	}
 	d := net.Dialer{
 		Timeout:   30 * time.Second,
 		KeepAlive: 30 * time.Second,
 	}
-	return &http.Client{Transport: &http.Transport{
-		TLSClientConfig:       &tls.Config{InsecureSkipVerify: true},
+	var rt http.RoundTripper = &http.Transport{
+		Proxy:                 proxy,
+		TLSClientConfig:       tc,
 		IdleConnTimeout:       90 * time.Second,
 		TLSHandshakeTimeout:   10 * time.Second,
-		ResponseHeaderTimeout: 10 * time.Second,
+		ResponseHeaderTimeout: o.HeaderTimeout,
 		ExpectContinueTimeout: time.Second,
-		MaxIdleConns:          10,
+		MaxIdleConns:          o.MaxIdleConns,
+		MaxIdleConnsPerHost:   o.MaxIdleConns,
+		MaxConnsPerHost:       o.MaxConnsPerHost,
 		DialContext:           d.DialContext,
-	}, Timeout: 10 * time.Second}
+		// a custom TLSClientConfig / DialContext disables HTTP/2 unless forced
+		ForceAttemptHTTP2: o.HTTP2,
+	}
+	if o.UserAgent != "" || len(o.Headers) != 0 {
+		rt = &headerTransport{next: rt, userAgent: o.UserAgent, headers: o.Headers}
+	}
+	return &http.Client{Transport: rt, Timeout: o.Timeout}, nil
+}
+
+// headerTransport sets the configured User-Agent and extra headers.
+type headerTransport struct {
+	next      http.RoundTripper
+	userAgent string
+	headers   http.Header
+}
+
+func (t *headerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the caller's request
+	r = r.Clone(r.Context())
+	for k, v := range t.headers {
+		r.Header[k] = append([]string(nil), v...)
+	}
+	if t.userAgent != "" {
+		r.Header.Set("User-Agent", t.userAgent)
+	}
+	return t.next.RoundTrip(r)
 }
 
 // endregion
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// bodies serves the given bodies in order, repeating the last one.
+func bodies(t *testing.T, l ...string) (*httptest.Server, *int32) {
+	t.Helper()
+	var n int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(atomic.AddInt32(&n, 1)) - 1
+		if i >= len(l) {
+			i = len(l) - 1
+		}
+		_, _ = w.Write([]byte(l[i]))
+	}))
+	t.Cleanup(s.Close)
+	return s, &n
+}
+
+func TestRequestRetriesTruncatedBody(t *testing.T) {
+	s, n := bodies(t, ``, `[{"a": `, `[{"a": 1}]`)
+	var resp []map[string]int
+	if err := From(http.DefaultClient).Request(context.Background(), s.URL, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp) != 1 || *n != 3 {
+		t.Errorf("got %v after %d requests", resp, *n)
+	}
+}
+
+func TestRequestPermanentErrors(t *testing.T) {
+	s, n := bodies(t, `{"a": "x"}`)
+	ts := newTLSServer(t)
+	wrongPin := TLSOptions{Insecure: true, Pins: []string{hex.EncodeToString(make([]byte, 32))}}
+	for _, tc := range []struct {
+		name string
+		opts *TLSOptions
+		u    string
+		want error
+	}{
+		{"decode", nil, s.URL, nil},
+		{"unknown authority", &TLSOptions{}, ts.URL, nil},
+		{"pin", &wrongPin, ts.URL, ErrPinMismatch},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var d Doer = http.DefaultClient
+			if tc.opts != nil {
+				var err error
+				if d, err = NewHTTPClient(Options{TLS: *tc.opts}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			var resp []map[string]int
+			err := From(d).Request(ctx, tc.u, &resp)
+			if !IsPermanent(err) {
+				t.Fatalf("got %v, want a permanent error", err)
+			}
+			if tc.want != nil && !errors.Is(err, tc.want) {
+				t.Errorf("got %v, want %v", err, tc.want)
+			}
+			if ctx.Err() != nil {
+				t.Error("retried until the deadline")
+			}
+		})
+	}
+	if *n != 1 {
+		t.Errorf("decode error retried: %d requests", *n)
+	}
+}
+
+func TestRequestDeadlineKeepsLastError(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusBadGateway)
+	}))
+	defer s.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var resp []int
+	err := From(http.DefaultClient).Request(ctx, s.URL, &resp)
+	if !errors.Is(err, context.DeadlineExceeded) || IsPermanent(err) {
+		t.Fatalf("got %v", err)
+	}
+	if want := "502 Bad Gateway"; !strings.Contains(err.Error(), want) {
+		t.Errorf("%v does not mention %q", err, want)
+	}
+}
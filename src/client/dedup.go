@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// region Dedup
+
+// DefaultMemoEndpoints are the hierarchy lists that do not change during a run.
+// Results are only shared while in flight; memoizing them would keep every
+// ballot box in memory.
+var DefaultMemoEndpoints = []string{
+	"getIlList", "getIlceList", "getMuhtarlikList", "getUlkeList",
+	"getDisTemsilcilikList", "getGumrukList", "getSandikSecimSonucBaslikList",
+}
+
+// DedupStats counts how many requests were served without a new fetch.
+type DedupStats struct {
+	Requests int64
+	Fetched  int64
+	Shared   int64
+	Memoized int64
+}
+
+// Saved is the number of requests that did not hit the network.
+func (s DedupStats) Saved() int64 {
+	return s.Shared + s.Memoized
+}
+
+func (s DedupStats) String() string {
+	return fmt.Sprintf("%d requests, %d fetched, %d shared in flight, %d memoized",
+		s.Requests, s.Fetched, s.Shared, s.Memoized)
+}
+
+// DedupClient is a Client decorator that fetches identical in-flight URLs once
+// and shares the response with every waiting caller. Responses of URLs
+// matching a memo endpoint are also kept for later identical requests.
+type DedupClient struct {
+	next  Client
+	memo  []string
+	mu    sync.Mutex
+	calls map[string]*dedupCall
+	stats DedupStats
+}
+
+type dedupCall struct {
+	done chan struct{}
+	buf  json.RawMessage
+	err  error
+	// canceled is set when the fetch failed because the leader's own
+	// context ended; the error then says nothing about the URL.
+	canceled bool
+}
+
+func Dedup(next Client, memoEndpoints []string) *DedupClient {
+	return &DedupClient{next: next, memo: memoEndpoints, calls: make(map[string]*dedupCall)}
+}
+
+func (d *DedupClient) memoized(uri string) bool {
+	for _, m := range d.memo {
+		if strings.Contains(uri, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// Request fetches uri through the leader of its in-flight call. The fetch runs
+// with the leader's context; if the leader is canceled, waiters whose own
+// context is still live retry, one of them becoming the new leader.
+func (d *DedupClient) Request(ctx context.Context, uri string, resp any) error {
+	atomic.AddInt64(&d.stats.Requests, 1)
+	for {
+		d.mu.Lock()
+		c, ok := d.calls[uri]
+		if !ok {
+			c = &dedupCall{done: make(chan struct{})}
+			d.calls[uri] = c
+			d.mu.Unlock()
+			return d.fetch(ctx, uri, c, resp)
+		}
+		d.mu.Unlock()
+		memo := false
+		select {
+		case <-c.done:
+			// a finished call still in the map is memoized; otherwise the
+			// leader finished between the lookup and here
+			memo = d.memoized(uri)
+		default:
+			select {
+			case <-c.done:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if c.canceled && ctx.Err() == nil {
+			continue
+		}
+		if memo {
+			atomic.AddInt64(&d.stats.Memoized, 1)
+		} else {
+			atomic.AddInt64(&d.stats.Shared, 1)
+		}
+		if c.err != nil {
+			return c.err
+		}
+		return json.Unmarshal(c.buf, resp)
+	}
+}
+
+// fetch runs the call as its leader; failed calls are evicted so the next
+// request fetches again.
+func (d *DedupClient) fetch(ctx context.Context, uri string, c *dedupCall, resp any) error {
+	atomic.AddInt64(&d.stats.Fetched, 1)
+	c.err = d.next.Request(ctx, uri, &c.buf)
+	c.canceled = c.err != nil && ctx.Err() != nil
+	if c.err != nil || !d.memoized(uri) {
+		d.mu.Lock()
+		delete(d.calls, uri)
+		d.mu.Unlock()
+	}
+	close(c.done)
+	if c.err != nil {
+		return c.err
+	}
+	return json.Unmarshal(c.buf, resp)
+}
+
+// Stats returns a snapshot of the counters.
+func (d *DedupClient) Stats() DedupStats {
+	return DedupStats{
+		Requests: atomic.LoadInt64(&d.stats.Requests),
+		Fetched:  atomic.LoadInt64(&d.stats.Fetched),
+		Shared:   atomic.LoadInt64(&d.stats.Shared),
+		Memoized: atomic.LoadInt64(&d.stats.Memoized),
+	}
+}
+
+// endregion
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fetcher is a Client backed by a function; it counts the calls per uri.
+type fetcher struct {
+	mu    sync.Mutex
+	calls map[string]int
+	fn    func(ctx context.Context, uri string, n int) (string, error)
+}
+
+func newFetcher(fn func(ctx context.Context, uri string, n int) (string, error)) *fetcher {
+	return &fetcher{calls: make(map[string]int), fn: fn}
+}
+
+func (f *fetcher) Request(ctx context.Context, uri string, resp any) error {
+	f.mu.Lock()
+	f.calls[uri]++
+	n := f.calls[uri]
+	f.mu.Unlock()
+	body, err := f.fn(ctx, uri, n)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(body), resp)
+}
+
+func TestDedupSharesInFlight(t *testing.T) {
+	release := make(chan struct{})
+	f := newFetcher(func(context.Context, string, int) (string, error) {
+		<-release
+		return `[1, 2]`, nil
+	})
+	d := Dedup(f, nil)
+	const n = 5
+	var wg sync.WaitGroup
+	var ok int32
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var l []int
+			if err := d.Request(context.Background(), "getSandikSecimSonucList?x=1", &l); err == nil && len(l) == 2 {
+				atomic.AddInt32(&ok, 1)
+			}
+		}()
+	}
+	// every caller must be waiting before the leader returns
+	for atomic.LoadInt64(&d.stats.Requests) < n {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	if ok != n {
+		t.Errorf("%d of %d callers got the response", ok, n)
+	}
+	st := d.Stats()
+	if st.Fetched != 1 || st.Shared != n-1 || st.Memoized != 0 || st.Saved() != n-1 {
+		t.Errorf("stats = %v", st)
+	}
+	// results of non-memo endpoints are not kept
+	var l []int
+	if err := d.Request(context.Background(), "getSandikSecimSonucList?x=1", &l); err != nil {
+		t.Fatal(err)
+	}
+	if st := d.Stats(); st.Fetched != 2 {
+		t.Errorf("finished call reused: %v", st)
+	}
+}
+
+func TestDedupMemoizes(t *testing.T) {
+	f := newFetcher(func(context.Context, string, int) (string, error) {
+		return `[{"il_ID": 6}]`, nil
+	})
+	d := Dedup(f, DefaultMemoEndpoints)
+	for i := 0; i < 3; i++ {
+		var l []map[string]int
+		if err := d.Request(context.Background(), "getIlList?secimId=1", &l); err != nil || l[0]["il_ID"] != 6 {
+			t.Fatalf("got %v, %v", l, err)
+		}
+	}
+	st := d.Stats()
+	if st.Requests != 3 || st.Fetched != 1 || st.Memoized != 2 || st.Saved() != 2 {
+		t.Errorf("stats = %v", st)
+	}
+}
+
+func TestDedupEvictsErrors(t *testing.T) {
+	fail := errors.New("down")
+	f := newFetcher(func(_ context.Context, _ string, n int) (string, error) {
+		if n == 1 {
+			return "", fail
+		}
+		return `[1]`, nil
+	})
+	d := Dedup(f, DefaultMemoEndpoints)
+	var l []int
+	if err := d.Request(context.Background(), "getIlList?secimId=1", &l); !errors.Is(err, fail) {
+		t.Fatalf("got %v, want %v", err, fail)
+	}
+	// the failed call is not memoized
+	if err := d.Request(context.Background(), "getIlList?secimId=1", &l); err != nil || len(l) != 1 {
+		t.Fatalf("got %v, %v", l, err)
+	}
+	if st := d.Stats(); st.Fetched != 2 || st.Saved() != 0 {
+		t.Errorf("stats = %v", st)
+	}
+}
+
+func TestDedupLeaderCanceled(t *testing.T) {
+	started := make(chan struct{})
+	f := newFetcher(func(ctx context.Context, _ string, n int) (string, error) {
+		if n == 1 {
+			close(started)
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return `[1]`, nil
+	})
+	d := Dedup(f, nil)
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		var l []int
+		leaderErr <- d.Request(leaderCtx, "getSandikSecimSonucList?x=1", &l)
+	}()
+	<-started
+	waiterErr := make(chan error, 1)
+	var l []int
+	go func() {
+		waiterErr <- d.Request(context.Background(), "getSandikSecimSonucList?x=1", &l)
+	}()
+	for atomic.LoadInt64(&d.stats.Requests) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("leader got %v", err)
+	}
+	// the waiter's context is live; it must not inherit the leader's cancellation
+	if err := <-waiterErr; err != nil || len(l) != 1 {
+		t.Errorf("waiter got %v, %v", l, err)
+	}
+	if st := d.Stats(); st.Fetched != 2 {
+		t.Errorf("stats = %v", st)
+	}
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"github.com/secim/src/metrics"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// region Metrics
+
+var (
+	requestsTotal = metrics.NewCounter("kokpit_http_requests_total",
+		"HTTP requests by endpoint and status code.", "endpoint", "status")
+	requestSeconds = metrics.NewHistogram("kokpit_http_request_duration_seconds",
+		"HTTP request latency until the response headers arrive.", metrics.DefBuckets, "endpoint")
+	responseBytes = metrics.NewCounter("kokpit_http_response_bytes_total",
+		"Response body bytes read by endpoint.", "endpoint")
+	retriesTotal = metrics.NewCounter("kokpit_http_retries_total",
+		"Failed requests that were retried, by endpoint.", "endpoint")
+)
+
+// Endpoint returns a low-cardinality label for a kokpit URL: the path after
+// /api/ without numeric IDs, e.g. ssps/getIlList or milletvekili/birim/SECIM_CEVRESI.
+func Endpoint(u *url.URL) string {
+	path := u.Path
+	if i := strings.Index(path, "/api/"); i >= 0 {
+		path = path[i+len("/api/"):]
+	}
+	var parts []string
+	for _, p := range strings.Split(path, "/") {
+		if _, err := strconv.Atoi(p); p != "" && err != nil {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// Totals returns the process wide number of HTTP requests and of failed
+// requests that were retried, for progress reporting.
+func Totals() (requests, retries float64) {
+	return requestsTotal.Total(), retriesTotal.Total()
+}
+
+func endpointOf(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "invalid"
+	}
+	return Endpoint(u)
+}
+
+// Instrument wraps a Doer to record request counts, latency and body size.
+func Instrument(d Doer) Doer {
+	return &instrumented{next: d}
+}
+
+type instrumented struct {
+	next Doer
+}
+
+func (d *instrumented) Do(r *http.Request) (*http.Response, error) {
+	ep := Endpoint(r.URL)
+	start := time.Now()
+	rs, err := d.next.Do(r)
+	requestSeconds.Observe(time.Since(start).Seconds(), ep)
+	if err != nil {
+		requestsTotal.Inc(ep, "error")
+		return rs, err
+	}
+	requestsTotal.Inc(ep, strconv.Itoa(rs.StatusCode))
+	rs.Body = &countingBody{ReadCloser: rs.Body, endpoint: ep}
+	return rs, nil
+}
+
+type countingBody struct {
+	io.ReadCloser
+	endpoint string
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	responseBytes.Add(float64(n), b.endpoint)
+	return n, err
+}
+
+// endregion
@@ -0,0 +1,36 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// region Redirect
+
+// Redirect returns a Doer that sends every request to origin
+// (scheme://host[:port]) keeping the path and query, e.g. to point the
+// fetcher at a mirror or at a local test server.
+func Redirect(d Doer, origin string) (Doer, error) {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("origin must be scheme://host[:port]: %q", origin)
+	}
+	return &redirected{next: d, scheme: u.Scheme, host: u.Host}, nil
+}
+
+type redirected struct {
+	next         Doer
+	scheme, host string
+}
+
+func (d *redirected) Do(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme, r.URL.Host, r.Host = d.scheme, d.host, ""
+	return d.next.Do(r)
+}
+
+// endregion
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// region TLS
+
+// TLSOptions controls how the server certificate is verified.
+// The zero value performs full verification against the system pool.
+type TLSOptions struct {
+	// CAFile is a PEM bundle trusted in addition to the system pool.
+	CAFile string
+	// Pins are SHA-256 hashes of the SPKI or the whole certificate, at least
+	// one of which must match the server chain. Hex or base64, with an
+	// optional "sha256/" prefix.
+	Pins []string
+	// Insecure disables chain verification. Pins are still checked.
+	Insecure bool
+}
+
+// ErrPinMismatch is returned when no certificate in the chain matches a pin.
+var ErrPinMismatch = errors.New("tls: server certificate does not match any pin")
+
+// Config builds the client TLS configuration for the options.
+func (o TLSOptions) Config() (*tls.Config, error) {
+	tc := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: o.Insecure}
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", o.CAFile)
+		}
+		tc.RootCAs = pool
+	}
+	if len(o.Pins) != 0 {
+		pins := make(map[string]bool, len(o.Pins))
+		for _, p := range o.Pins {
+			b, err := decodePin(p)
+			if err != nil {
+				return nil, err
+			}
+			pins[string(b)] = true
+		}
+		// VerifyConnection runs after chain verification, and also when Insecure
+		tc.VerifyConnection = func(cs tls.ConnectionState) error {
+			for _, cert := range cs.PeerCertificates {
+				spki, raw := sha256.Sum256(cert.RawSubjectPublicKeyInfo), sha256.Sum256(cert.Raw)
+				if pins[string(spki[:])] || pins[string(raw[:])] {
+					return nil
+				}
+			}
+			return ErrPinMismatch
+		}
+	}
+	return tc, nil
+}
+
+func decodePin(p string) ([]byte, error) {
+	p = strings.TrimPrefix(strings.TrimSpace(p), "sha256/")
+	if b, err := hex.DecodeString(p); err == nil && len(b) == sha256.Size {
+		return b, nil
+	}
+	if b, err := base64.StdEncoding.DecodeString(p); err == nil && len(b) == sha256.Size {
+		return b, nil
+	}
+	return nil, fmt.Errorf("invalid sha256 pin: %q", p)
+}
+
+// SPKIPin returns the base64 SPKI hash of cert in the form accepted by Pins.
+func SPKIPin(cert *x509.Certificate) string {
+	h := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256/" + base64.StdEncoding.EncodeToString(h[:])
+}
+
+// endregion
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTLSServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func writeCAFile(t *testing.T, s *httptest.Server) string {
+	t.Helper()
+	fn := filepath.Join(t.TempDir(), "ca.pem")
+	b := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw})
+	if err := os.WriteFile(fn, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return fn
+}
+
+func doGet(t *testing.T, o TLSOptions, u string) error {
+	t.Helper()
+	d, err := NewHTTPClient(Options{TLS: o})
+	if err != nil {
+		t.Fatalf("NewHTTPClient: %v", err)
+	}
+	rq, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := d.Do(rq)
+	if err == nil {
+		_ = rs.Body.Close()
+	}
+	return err
+}
+
+func TestTLSVerification(t *testing.T) {
+	s := newTLSServer(t)
+	ca := writeCAFile(t, s)
+	raw := sha256.Sum256(s.Certificate().Raw)
+	for _, tc := range []struct {
+		name string
+		opts TLSOptions
+		ok   bool
+	}{
+		{"default rejects unknown CA", TLSOptions{}, false},
+		{"custom CA", TLSOptions{CAFile: ca}, true},
+		{"custom CA with SPKI pin", TLSOptions{CAFile: ca, Pins: []string{SPKIPin(s.Certificate())}}, true},
+		{"custom CA with cert hash pin", TLSOptions{CAFile: ca, Pins: []string{hex.EncodeToString(raw[:])}}, true},
+		{"custom CA with wrong pin", TLSOptions{CAFile: ca, Pins: []string{hex.EncodeToString(make([]byte, 32))}}, false},
+		{"insecure", TLSOptions{Insecure: true}, true},
+		{"insecure with matching pin", TLSOptions{Insecure: true, Pins: []string{SPKIPin(s.Certificate())}}, true},
+		{"insecure with wrong pin", TLSOptions{Insecure: true, Pins: []string{hex.EncodeToString(make([]byte, 32))}}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := doGet(t, tc.opts, s.URL)
+			if tc.ok && err != nil {
+				t.Errorf("expected success, got %v", err)
+			} else if !tc.ok && err == nil {
+				t.Errorf("expected TLS failure")
+			}
+		})
+	}
+}
+
+func TestTLSOptionsErrors(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a cert"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for name, o := range map[string]TLSOptions{
+		"missing CA file":       {CAFile: filepath.Join(dir, "yok.pem")},
+		"CA file without certs": {CAFile: empty},
+		"short pin":             {Pins: []string{"abcd"}},
+		"garbage pin":           {Pins: []string{"sha256/!!!"}},
+	} {
+		if _, err := o.Config(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package src
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// region DVOWalker
+
+// DVOVisitor agactaki her birim icin cagrilir. path kokten baslayip
+// ziyaret edilen birimle biten ust birim zinciridir; visitor path'i
+// saklayacaksa kopyalamalidir. false donerse alt birimlere inilmez.
+type DVOVisitor func(path []*DVOData) bool
+
+// WalkDVO root'tan baslayarak AltBirimDVOs agacini derinlik oncelikli gezer
+func WalkDVO(root *DVOData, fn DVOVisitor) {
+	walkDVO([]*DVOData{root}, fn)
+}
+
+func walkDVO(path []*DVOData, fn DVOVisitor) {
+	if !fn(path) {
+		return
+	}
+	d := path[len(path)-1]
+	for i := range d.AltBirimDVOs {
+		// kardes birimler ayni backing array'i ezmesin diye kapasiteyi kirp
+		walkDVO(append(path[:len(path):len(path)], &d.AltBirimDVOs[i]), fn)
+	}
+}
+
+// endregion
+// region DVORow
+
+// DVORow agactaki tek bir birimin duzlestirilmis hali
+type DVORow struct {
+	Derinlik     int      `json:"derinlik"`
+	Yol          []string `json:"yol"`
+	Turler       []string `json:"turler"`
+	BirimID      int      `json:"birim_ID"`
+	BirimADI     string   `json:"birim_ADI"`
+	Turu         string   `json:"turu"`
+	UstBIRIM     any      `json:"ust_BIRIM"`
+	UstBIRIMTURU any      `json:"ust_BIRIM_TURU"`
+	// UstBirim* agactaki ust birimden gelir; kokte bostur
+	UstBirimID                int            `json:"ustBirimId,omitempty"`
+	UstBirimADI               string         `json:"ustBirimAdi,omitempty"`
+	UstBirimTURU              string         `json:"ustBirimTuru,omitempty"`
+	Version                   string         `json:"version"`
+	ToplamSandikSayisi        int            `json:"toplamSandikSayisi"`
+	AcilanSandikSayisi        int            `json:"acilanSandikSayisi"`
+	KayitliSecmenSayisi       int            `json:"kayitliSecmenSayisi"`
+	AcilanKayitliSecmenSayisi int            `json:"acilanKayitliSecmenSayisi"`
+	OyKullananSecmenSayisi    int            `json:"oyKullananSecmenSayisi"`
+	GecerliOyToplami          int            `json:"gecerliOyToplami"`
+	GecersizOyToplami         int            `json:"gecersizOyToplami"`
+	Partiler                  map[string]int `json:"partiler"`
+	Ittifaklar                map[string]int `json:"ittifaklar"`
+}
+
+// FlattenDVO agaci kok birim ilk satir olacak sekilde satirlara acar
+func FlattenDVO(root *DVOData) []DVORow {
+	var rows []DVORow
+	WalkDVO(root, func(path []*DVOData) bool {
+		d := path[len(path)-1]
+		row := DVORow{
+			Derinlik: len(path) - 1, Yol: make([]string, 0, len(path)), Turler: make([]string, 0, len(path)),
+			BirimID: d.BirimID, BirimADI: d.BirimADI, Turu: d.Turu,
+			UstBIRIM: d.UstBIRIM, UstBIRIMTURU: d.UstBIRIMTURU, Version: d.Version,
+			ToplamSandikSayisi: d.ToplamSandikSayisi, AcilanSandikSayisi: d.AcilanSandikSayisi,
+			KayitliSecmenSayisi: d.KayitliSecmenSayisi, AcilanKayitliSecmenSayisi: d.AcilanKayitliSecmenSayisi,
+			OyKullananSecmenSayisi: d.OyKullananSecmenSayisi,
+			GecerliOyToplami:       d.GecerliOyToplami, GecersizOyToplami: d.GecersizOyToplami,
+			Partiler: make(map[string]int), Ittifaklar: make(map[string]int),
+		}
+		if len(path) > 1 {
+			ust := path[len(path)-2]
+			row.UstBirimID, row.UstBirimADI, row.UstBirimTURU = ust.BirimID, ust.BirimADI, ust.Turu
+		}
+		for _, p := range path {
+			row.Yol = append(row.Yol, p.BirimADI)
+			row.Turler = append(row.Turler, p.Turu)
+		}
+		for _, p := range d.PartiDVOs {
+			row.Partiler[partiKey(p)] += p.Oy
+		}
+		for _, i := range d.IttifakDVOs {
+			row.Ittifaklar[i.IttifakUnvani] += i.Oy
+		}
+		rows = append(rows, row)
+		return true
+	})
+	return rows
+}
+
+// kisa ad bos gelen partiler icin uzun ada dus
+func partiKey(p PartiDVOData) string {
+	if p.PartiKisaAdi != "" {
+		return p.PartiKisaAdi
+	}
+	return p.PartiAdi
+}
+
+// endregion
+// region DVOExport
+
+// WriteDVOJSON agaclarin duzlestirilmis satirlarini tek bir JSON dizisi olarak yazar
+func WriteDVOJSON(w io.Writer, roots ...*DVOData) error {
+	rows := make([]DVORow, 0)
+	for _, root := range roots {
+		rows = append(rows, FlattenDVO(root)...)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rows)
+}
+
+// WriteDVOCSV agaclarin duzlestirilmis satirlarini CSV olarak yazar.
+// Parti ve ittifak sutunlari tum birimlerin birlesimidir, sira no'ya gore dizilir.
+// Ust birim sutunlari agaci duz dosyadan yeniden kurmaya yeter.
+func WriteDVOCSV(w io.Writer, roots ...*DVOData) error {
+	partiler, ittifaklar := dvoSutunlar(roots)
+	cw := csv.NewWriter(w)
+	header := []string{
+		"DERINLIK", "YOL", "TURLER", "BIRIM ID", "BIRIM ADI", "TURU",
+		"UST BIRIM ID", "UST BIRIM ADI", "UST BIRIM TURU", "VERSION",
+		"TOPLAM SANDIK", "ACILAN SANDIK", "KAYITLI SECMEN", "ACILAN KAYITLI SECMEN",
+		"OY KULLANAN SECMEN", "GECERLI OY", "GECERSIZ OY",
+	}
+	header = append(append(header, ittifaklar...), partiler...)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	var rows []DVORow
+	for _, root := range roots {
+		rows = append(rows, FlattenDVO(root)...)
+	}
+	for _, r := range rows {
+		rec := []string{
+			fmt.Sprint(r.Derinlik), strings.Join(r.Yol, " / "), strings.Join(r.Turler, " / "),
+			fmt.Sprint(r.BirimID), r.BirimADI, r.Turu,
+		}
+		rec = append(append(rec, ustBirim(r)...), r.Version,
+			fmt.Sprint(r.ToplamSandikSayisi), fmt.Sprint(r.AcilanSandikSayisi),
+			fmt.Sprint(r.KayitliSecmenSayisi), fmt.Sprint(r.AcilanKayitliSecmenSayisi),
+			fmt.Sprint(r.OyKullananSecmenSayisi), fmt.Sprint(r.GecerliOyToplami), fmt.Sprint(r.GecersizOyToplami),
+		)
+		for _, i := range ittifaklar {
+			rec = append(rec, optInt(r.Ittifaklar, i))
+		}
+		for _, p := range partiler {
+			rec = append(rec, optInt(r.Partiler, p))
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ustBirim agactaki ust birimin id, ad ve turu; kokun ust birimi agacta
+// olmadigi icin api'nin ust_BIRIM alanlari yazilir
+func ustBirim(r DVORow) []string {
+	if r.Derinlik > 0 {
+		return []string{fmt.Sprint(r.UstBirimID), r.UstBirimADI, r.UstBirimTURU}
+	}
+	return []string{optAny(r.UstBIRIM), "", optAny(r.UstBIRIMTURU)}
+}
+
+// optAny null api alanlarini bos hucre yazar; sayilar json'dan float64 gelir
+func optAny(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return fmt.Sprint(int64(v))
+	}
+	return fmt.Sprint(v)
+}
+
+func optInt(m map[string]int, k string) string {
+	if v, ok := m[k]; ok {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// dvoSutunlar agactaki tum parti ve ittifaklari sira numaralarina gore dizer
+func dvoSutunlar(roots []*DVOData) (partiler, ittifaklar []string) {
+	pSira, iSira := make(map[string]int), make(map[string]int)
+	visit := func(path []*DVOData) bool {
+		d := path[len(path)-1]
+		for _, p := range d.PartiDVOs {
+			if _, ok := pSira[partiKey(p)]; !ok {
+				pSira[partiKey(p)] = p.PartiSira
+			}
+		}
+		for _, i := range d.IttifakDVOs {
+			if _, ok := iSira[i.IttifakUnvani]; !ok {
+				iSira[i.IttifakUnvani] = i.IttifakSiraNo
+			}
+		}
+		return true
+	}
+	for _, root := range roots {
+		WalkDVO(root, visit)
+	}
+	return sortedBySira(pSira), sortedBySira(iSira)
+}
+
+func sortedBySira(m map[string]int) []string {
+	l := make([]string, 0, len(m))
+	for k := range m {
+		l = append(l, k)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		if m[l[i]] != m[l[j]] {
+			return m[l[i]] < m[l[j]]
+		}
+		return l[i] < l[j]
+	})
+	return l
+}
+
+// endregion
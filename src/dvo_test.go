@@ -0,0 +1,146 @@
+package src
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// dvoAgaci ulke > iki cevre > ilceler; derin kardesler path'in ayni
+// backing array'i paylasip paylasmadigini ortaya cikarir
+func dvoAgaci() *DVOData {
+	birim := func(id int, ad, turu string, alt ...DVOData) DVOData {
+		return DVOData{BirimID: id, BirimADI: ad, Turu: turu, AltBirimDVOs: alt}
+	}
+	kok := birim(1, "TÜRKİYE", "ULKE",
+		birim(10, "ANKARA 1", "SECIM_CEVRESI",
+			birim(100, "ÇANKAYA", "ILCE", birim(1000, "MAHALLE 1", "MUHTARLIK"), birim(1001, "MAHALLE 2", "MUHTARLIK")),
+			birim(101, "SİNCAN", "ILCE", birim(1010, "MAHALLE 3", "MUHTARLIK"))),
+		birim(20, "İZMİR 1", "SECIM_CEVRESI",
+			birim(200, "KONAK", "ILCE")))
+	return &kok
+}
+
+func adlar(path []*DVOData) string {
+	l := make([]string, 0, len(path))
+	for _, d := range path {
+		l = append(l, d.BirimADI)
+	}
+	return strings.Join(l, "/")
+}
+
+func TestWalkDVOKardesYollari(t *testing.T) {
+	// path kasten kopyalanmadan saklanir: kardesler birbirinin yolunu ezmemeli
+	var saklanan [][]*DVOData
+	var anlik []string
+	WalkDVO(dvoAgaci(), func(path []*DVOData) bool {
+		saklanan = append(saklanan, path)
+		anlik = append(anlik, adlar(path))
+		return true
+	})
+	want := []string{
+		"TÜRKİYE",
+		"TÜRKİYE/ANKARA 1",
+		"TÜRKİYE/ANKARA 1/ÇANKAYA",
+		"TÜRKİYE/ANKARA 1/ÇANKAYA/MAHALLE 1",
+		"TÜRKİYE/ANKARA 1/ÇANKAYA/MAHALLE 2",
+		"TÜRKİYE/ANKARA 1/SİNCAN",
+		"TÜRKİYE/ANKARA 1/SİNCAN/MAHALLE 3",
+		"TÜRKİYE/İZMİR 1",
+		"TÜRKİYE/İZMİR 1/KONAK",
+	}
+	if !reflect.DeepEqual(anlik, want) {
+		t.Fatalf("visit order:\n got %v\nwant %v", anlik, want)
+	}
+	for i, path := range saklanan {
+		if got := adlar(path); got != want[i] {
+			t.Errorf("retained path %d overwritten: %s, want %s", i, got, want[i])
+		}
+	}
+}
+
+func TestWalkDVOBuda(t *testing.T) {
+	var got []string
+	WalkDVO(dvoAgaci(), func(path []*DVOData) bool {
+		d := path[len(path)-1]
+		got = append(got, d.BirimADI)
+		// ANKARA 1'in ilceleri ve ilcelerin muhtarliklari gezilmez
+		return d.BirimID != 10 && d.Turu != "ILCE"
+	})
+	want := []string{"TÜRKİYE", "ANKARA 1", "İZMİR 1", "KONAK"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFlattenDVO(t *testing.T) {
+	rows := FlattenDVO(dvoAgaci())
+	if len(rows) != 9 {
+		t.Fatalf("got %d rows", len(rows))
+	}
+	r := rows[4]
+	if r.Derinlik != 3 || r.BirimID != 1001 || r.UstBirimID != 100 || r.UstBirimADI != "ÇANKAYA" || r.UstBirimTURU != "ILCE" ||
+		!reflect.DeepEqual(r.Yol, []string{"TÜRKİYE", "ANKARA 1", "ÇANKAYA", "MAHALLE 2"}) ||
+		!reflect.DeepEqual(r.Turler, []string{"ULKE", "SECIM_CEVRESI", "ILCE", "MUHTARLIK"}) {
+		t.Errorf("unexpected row %+v", r)
+	}
+	if r := rows[0]; r.UstBirimID != 0 || r.UstBirimADI != "" {
+		t.Errorf("root has a parent: %+v", r)
+	}
+}
+
+func TestWriteDVOCSVSutunBirlesimi(t *testing.T) {
+	a := DVOData{BirimID: 1, BirimADI: "ANKARA 1", Turu: "SECIM_CEVRESI",
+		PartiDVOs: []PartiDVOData{
+			{PartiAdi: "B PARTİSİ", PartiKisaAdi: "B", PartiSira: 2, Oy: 30},
+			{PartiAdi: "A PARTİSİ", PartiKisaAdi: "A", PartiSira: 1, Oy: 50},
+		},
+		IttifakDVOs: []IttifakDVOData{{IttifakUnvani: "CUMHUR", IttifakSiraNo: 1, Oy: 80}},
+		AltBirimDVOs: []DVOData{{BirimID: 2, BirimADI: "ÇANKAYA", Turu: "ILCE",
+			// kisa adi bos parti uzun adla yazilir
+			PartiDVOs: []PartiDVOData{{PartiAdi: "C PARTİSİ", PartiSira: 3, Oy: 7}}}},
+	}
+	b := DVOData{BirimID: 3, BirimADI: "İZMİR 1", Turu: "SECIM_CEVRESI", UstBIRIM: float64(9), UstBIRIMTURU: "ULKE",
+		PartiDVOs:   []PartiDVOData{{PartiAdi: "A PARTİSİ", PartiKisaAdi: "A", PartiSira: 1, Oy: 40}},
+		IttifakDVOs: []IttifakDVOData{{IttifakUnvani: "MILLET", IttifakSiraNo: 2, Oy: 40}},
+	}
+	var buf bytes.Buffer
+	if err := WriteDVOCSV(&buf, &a, &b); err != nil {
+		t.Fatal(err)
+	}
+	recs, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 4 {
+		t.Fatalf("got %d records", len(recs))
+	}
+	const sabit = 17
+	if got, want := recs[0][sabit:], []string{"CUMHUR", "MILLET", "A", "B", "C PARTİSİ"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("vote columns = %v, want %v", got, want)
+	}
+	for i, want := range [][]string{
+		{"80", "", "50", "30", ""},
+		{"", "", "", "", "7"},
+		{"", "40", "40", "", ""},
+	} {
+		if got := recs[i+1][sabit:]; !reflect.DeepEqual(got, want) {
+			t.Errorf("row %d (%s) votes = %v, want %v", i, recs[i+1][4], got, want)
+		}
+	}
+	if recs[2][1] != "ANKARA 1 / ÇANKAYA" || recs[3][0] != "0" {
+		t.Errorf("unexpected path columns: %v, %v", recs[2], recs[3])
+	}
+	// ust birim agactan, kok icin api alanlarindan gelir
+	for i, want := range [][]string{
+		{"", "", ""},
+		{"1", "ANKARA 1", "SECIM_CEVRESI"},
+		{"9", "", "ULKE"},
+	} {
+		if got := recs[i+1][6:9]; !reflect.DeepEqual(got, want) {
+			t.Errorf("row %d parent = %v, want %v", i, got, want)
+		}
+	}
+}
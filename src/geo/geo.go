@@ -0,0 +1,224 @@
+package geo
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/secim/src"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Tur bir cografi birimin seviyesi
+type Tur string
+
+const (
+	Il             Tur = "il"
+	SecimCevresi   Tur = "cevre"
+	Ilce           Tur = "ilce"
+	Muhtarlik      Tur = "muhtarlik"
+	Ulke           Tur = "ulke"
+	DisTemsilcilik Tur = "temsilcilik"
+	Gumruk         Tur = "gumruk"
+)
+
+func (t Tur) ord() int {
+	for i, o := range []Tur{Il, SecimCevresi, Ilce, Muhtarlik, Ulke, DisTemsilcilik, Gumruk} {
+		if t == o {
+			return i
+		}
+	}
+	return 99
+}
+
+// BirimID tur ve api id'sinden veri seti icinde tekil bir id uretir (ornek: ilce-815).
+// Api id'leri turler arasinda cakisabildigi icin ust baglantilari bu id'lerle kurulur.
+func BirimID(t Tur, id int) string {
+	return fmt.Sprintf("%s-%d", t, id)
+}
+
+// Birim referans veri setinin bir satiri. UstID bir ust seviyedeki birimin
+// BirimID'si; il ve ulkeler en ust seviyededir, gumruk kapilari bulunduklari
+// ilceye baglanir. BirimNo api'nin ilce birim id'si (DVO agacindaki birimId).
+type Birim struct {
+	ID             string `json:"id"`
+	Tur            Tur    `json:"tur"`
+	KaynakID       int    `json:"kaynak_ID"`
+	Ad             string `json:"ad"`
+	UstID          string `json:"ust_ID,omitempty"`
+	IlID           int    `json:"il_ID,omitempty"`
+	SecimCevresiID int    `json:"secim_CEVRESI_ID,omitempty"`
+	IlceID         int    `json:"ilce_ID,omitempty"`
+	BeldeID        int    `json:"belde_ID,omitempty"`
+	BirimNo        int    `json:"birim_ID,omitempty"`
+	UlkeID         int    `json:"ulke_ID,omitempty"`
+	SecilecekAday  int    `json:"secilecek_ADAY_SAYISI,omitempty"`
+	MinSandikNO    int    `json:"min_SANDIK_NO,omitempty"`
+	MaxSandikNO    int    `json:"max_SANDIK_NO,omitempty"`
+	MinSandikRUMUZ string `json:"min_SANDIK_RUMUZ,omitempty"`
+	MaxSandikRUMUZ string `json:"max_SANDIK_RUMUZ,omitempty"`
+}
+
+// Veri api listelerinden toplanan normalize cografi referans veri seti.
+// Ayni id ile ikinci kez eklenen birimler yok sayilir; Eszamanli kullanima uygundur.
+type Veri struct {
+	mu       sync.Mutex
+	birimler map[string]Birim
+}
+
+func New() *Veri {
+	return &Veri{birimler: make(map[string]Birim)}
+}
+
+func (v *Veri) ekle(b Birim) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.birimler[b.ID]; !ok {
+		v.birimler[b.ID] = b
+	}
+}
+
+// cevreNo il adlarinin sonundaki secim cevresi numarasi: "İSTANBUL 1"
+var cevreNo = regexp.MustCompile(`\s+\d+$`)
+
+// IlAdi secim cevresi adindan il adini cikarir: "İSTANBUL 1" -> "İSTANBUL"
+func IlAdi(cevre string) string {
+	return cevreNo.ReplaceAllString(strings.TrimSpace(cevre), "")
+}
+
+// AddCevre secim cevresini ve ilini ekler. Birden fazla cevresi olan illerin
+// adi cevre numarasi atilarak yazilir.
+func (v *Veri) AddCevre(i src.Il) {
+	il := BirimID(Il, i.IlID)
+	v.ekle(Birim{ID: il, Tur: Il, KaynakID: i.IlID, Ad: IlAdi(i.IlADI),
+		IlID: i.IlID})
+	v.ekle(Birim{ID: BirimID(SecimCevresi, i.SecimCEVRESIID), Tur: SecimCevresi, KaynakID: i.SecimCEVRESIID,
+		Ad: i.IlADI, UstID: il, IlID: i.IlID, SecimCevresiID: i.SecimCEVRESIID, SecilecekAday: i.SecilecekADAYSAYISI})
+}
+
+// IlceKimligi ilcenin BirimID'si; belde birimleri ilcenin altinda ayri tutulur
+func IlceKimligi(i src.Ilce) string {
+	if i.BeldeID != 0 {
+		return BirimID(Ilce, i.IlceID) + "." + BirimID("belde", i.BeldeID)
+	}
+	return BirimID(Ilce, i.IlceID)
+}
+
+func (v *Veri) AddIlce(i src.Ilce) {
+	v.ekle(Birim{ID: IlceKimligi(i), Tur: Ilce, KaynakID: i.IlceID, Ad: i.IlceADI,
+		UstID: BirimID(SecimCevresi, i.SecimCEVRESIID), IlID: i.IlID, SecimCevresiID: i.SecimCEVRESIID,
+		IlceID: i.IlceID, BeldeID: i.BeldeID, BirimNo: i.BirimID})
+}
+
+func (v *Veri) AddMuhtarlik(i src.Ilce, m src.Muh) {
+	b := Birim{ID: BirimID(Muhtarlik, m.MuhtarlikID), Tur: Muhtarlik, KaynakID: m.MuhtarlikID, Ad: m.MuhtarlikADI,
+		UstID: IlceKimligi(i), IlID: i.IlID, SecimCevresiID: i.SecimCEVRESIID, IlceID: i.IlceID,
+		BeldeID: i.BeldeID, BirimNo: i.BirimID}
+	b.aralik(m.SandikAraligi())
+	v.ekle(b)
+}
+
+func (v *Veri) AddUlke(u src.Ulke) {
+	v.ekle(Birim{ID: BirimID(Ulke, u.UlkeID), Tur: Ulke, KaynakID: u.UlkeID, Ad: u.UlkeADI, UlkeID: u.UlkeID})
+}
+
+func (v *Veri) AddDisTemsilcilik(d src.DisTemsilcilik) {
+	b := Birim{ID: BirimID(DisTemsilcilik, d.DisTEMSILCILIKID), Tur: DisTemsilcilik, KaynakID: d.DisTEMSILCILIKID,
+		Ad: d.DisTEMSILCILIKADI, UstID: BirimID(Ulke, d.UlkeID), UlkeID: d.UlkeID,
+		MinSandikRUMUZ: strings.TrimSpace(d.MinSANDIKRUMUZ), MaxSandikRUMUZ: strings.TrimSpace(d.MaxSANDIKRUMUZ)}
+	b.aralik(d.SandikAraligi())
+	v.ekle(b)
+}
+
+// AddGumruk gumruk kapisini ekler; ilcesi biliniyorsa ust birimi o ilcedir
+func (v *Veri) AddGumruk(g src.Gumruk) {
+	b := Birim{ID: BirimID(Gumruk, g.GumrukID), Tur: Gumruk, KaynakID: g.GumrukID, Ad: g.GumrukADI, IlceID: g.IlceID,
+		MinSandikRUMUZ: strings.TrimSpace(g.MinSANDIKRUMUZ), MaxSandikRUMUZ: strings.TrimSpace(g.MaxSANDIKRUMUZ)}
+	if g.IlceID != 0 {
+		b.UstID = BirimID(Ilce, g.IlceID)
+	}
+	b.aralik(g.SandikAraligi())
+	v.ekle(b)
+}
+
+// aralik okunabilen sandik no araligini yazar; bos aralik birimi dusurmez
+func (b *Birim) aralik(a src.SandikAraligi, err error) {
+	if err == nil {
+		b.MinSandikNO, b.MaxSandikNO = a.Ilk, a.Son
+	}
+}
+
+// Birimler kayitlari tur, sonra ust id, ad ve id'ye gore dizili doner
+func (v *Veri) Birimler() []Birim {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	l := make([]Birim, 0, len(v.birimler))
+	for _, b := range v.birimler {
+		l = append(l, b)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].Tur != l[j].Tur {
+			return l[i].Tur.ord() < l[j].Tur.ord()
+		}
+		if l[i].UstID != l[j].UstID {
+			return l[i].UstID < l[j].UstID
+		}
+		if l[i].Ad != l[j].Ad {
+			return l[i].Ad < l[j].Ad
+		}
+		return l[i].ID < l[j].ID
+	})
+	return l
+}
+
+// Sayilar tur basina birim sayilarini doner
+func (v *Veri) Sayilar() map[Tur]int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	m := make(map[Tur]int)
+	for _, b := range v.birimler {
+		m[b.Tur]++
+	}
+	return m
+}
+
+// WriteCSV birimleri tek bir referans tablosu olarak yazar; bos id'ler bos hucredir
+func (v *Veri) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"ID", "TUR", "KAYNAK ID", "AD", "UST ID", "IL ID", "SECIM CEVRESI ID", "ILCE ID",
+		"BELDE ID", "BIRIM ID", "ULKE ID", "SECILECEK ADAY SAYISI", "MIN SANDIK NO", "MAX SANDIK NO",
+		"MIN SANDIK RUMUZ", "MAX SANDIK RUMUZ"}); err != nil {
+		return err
+	}
+	id := func(n int) string {
+		if n == 0 {
+			return ""
+		}
+		return strconv.Itoa(n)
+	}
+	for _, b := range v.Birimler() {
+		if err := cw.Write([]string{
+			b.ID, string(b.Tur), strconv.Itoa(b.KaynakID), b.Ad, b.UstID, id(b.IlID), id(b.SecimCevresiID),
+			id(b.IlceID), id(b.BeldeID), id(b.BirimNo), id(b.UlkeID), id(b.SecilecekAday),
+			id(b.MinSandikNO), id(b.MaxSandikNO), b.MinSandikRUMUZ, b.MaxSandikRUMUZ,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON birimleri tur basina sayilarla birlikte tek bir JSON nesnesi olarak yazar
+func (v *Veri) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Sayilar  map[Tur]int `json:"sayilar"`
+		Birimler []Birim     `json:"birimler"`
+	}{v.Sayilar(), v.Birimler()})
+}
@@ -0,0 +1,92 @@
+package geo
+
+import (
+	"github.com/secim/src"
+	"reflect"
+	"testing"
+)
+
+func TestIlAdi(t *testing.T) {
+	for in, want := range map[string]string{
+		"İSTANBUL 1":  "İSTANBUL",
+		" İZMİR 2 ":   "İZMİR",
+		"ANKARA":      "ANKARA",
+		"YURT DIŞI":   "YURT DIŞI",
+		"19 MAYIS":    "19 MAYIS",
+		"KAHRAMAN 12": "KAHRAMAN",
+	} {
+		if got := IlAdi(in); got != want {
+			t.Errorf("IlAdi(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBirimler(t *testing.T) {
+	v := New()
+	ist1 := src.Il{IlID: 34, IlADI: "İSTANBUL 1", SecimCEVRESIID: 404001, SecilecekADAYSAYISI: 35}
+	ist2 := src.Il{IlID: 34, IlADI: "İSTANBUL 2", SecimCEVRESIID: 404002}
+	v.AddCevre(ist2)
+	v.AddCevre(ist1)
+	kadikoy := src.Ilce{IlceID: 420, IlceADI: "KADIKÖY", BirimID: 9, IlID: 34, SecimCEVRESIID: 404001}
+	belde := src.Ilce{IlceID: 420, IlceADI: "KADIKÖY", BeldeID: 12, IlID: 34, SecimCEVRESIID: 404001}
+	v.AddIlce(kadikoy)
+	v.AddIlce(belde)
+	// ayni id ile ikinci kayit yok sayilir
+	v.AddIlce(src.Ilce{IlceID: 420, IlceADI: "BASKA"})
+	v.AddMuhtarlik(belde, src.Muh{MuhtarlikID: 7, MuhtarlikADI: "MODA", MinSANDIKNO: "10", MaxSANDIKNO: "12"})
+	v.AddUlke(src.Ulke{UlkeID: 9988, UlkeADI: "ALMANYA"})
+	v.AddDisTemsilcilik(src.DisTemsilcilik{DisTEMSILCILIKID: 14, DisTEMSILCILIKADI: "BERLIN BK", UlkeID: 9988,
+		MinSANDIKNO: "1", MaxSANDIKNO: "3", MinSANDIKRUMUZ: " 1001 A", MaxSANDIKRUMUZ: "1003 A "})
+	v.AddGumruk(src.Gumruk{GumrukID: 7, GumrukADI: "KAPIKULE", IlceID: 1101})
+	v.AddGumruk(src.Gumruk{GumrukID: 8, GumrukADI: "BILINMEYEN"})
+
+	l := v.Birimler()
+	var ids []string
+	ust := make(map[string]string)
+	for _, b := range l {
+		ids = append(ids, b.ID)
+		ust[b.ID] = b.UstID
+	}
+	want := []string{
+		"il-34",
+		"cevre-404001", "cevre-404002",
+		"ilce-420", "ilce-420.belde-12",
+		"muhtarlik-7",
+		"ulke-9988",
+		"temsilcilik-14",
+		// ust id'siz kapi once
+		"gumruk-8", "gumruk-7",
+	}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("order:\n got %v\nwant %v", ids, want)
+	}
+	for id, want := range map[string]string{
+		"il-34": "", "cevre-404001": "il-34", "ilce-420": "cevre-404001", "ilce-420.belde-12": "cevre-404001",
+		"muhtarlik-7": "ilce-420.belde-12", "ulke-9988": "", "temsilcilik-14": "ulke-9988",
+		"gumruk-7": "ilce-1101", "gumruk-8": "",
+	} {
+		if ust[id] != want {
+			t.Errorf("%s parent = %q, want %q", id, ust[id], want)
+		}
+	}
+
+	il := l[0]
+	if il.Ad != "İSTANBUL" || il.KaynakID != 34 || il.Tur != Il {
+		t.Errorf("il = %+v", il)
+	}
+	if b := l[1]; b.Ad != "İSTANBUL 1" || b.SecilecekAday != 35 {
+		t.Errorf("cevre = %+v", b)
+	}
+	if b := l[3]; b.Ad != "KADIKÖY" || b.BirimNo != 9 {
+		t.Errorf("ilce = %+v", b)
+	}
+	if b := l[5]; b.MinSandikNO != 10 || b.MaxSandikNO != 12 || b.BeldeID != 12 {
+		t.Errorf("muhtarlik = %+v", b)
+	}
+	if b := l[7]; b.MinSandikNO != 1 || b.MaxSandikNO != 3 || b.MinSandikRUMUZ != "1001 A" || b.MaxSandikRUMUZ != "1003 A" {
+		t.Errorf("temsilcilik = %+v", b)
+	}
+	if got := v.Sayilar(); got[Il] != 1 || got[SecimCevresi] != 2 || got[Ilce] != 2 || got[Gumruk] != 2 {
+		t.Errorf("Sayilar() = %v", got)
+	}
+}
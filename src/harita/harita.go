@@ -0,0 +1,350 @@
+package harita
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/secim/src"
+	"github.com/secim/src/geo"
+	"github.com/secim/src/registry"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// region Toplam
+
+// Seviye sonuclarin toplandigi ve sinirlarin eslendigi idari seviye
+type Seviye string
+
+const (
+	IlSeviyesi   Seviye = "il"
+	IlceSeviyesi Seviye = "ilce"
+)
+
+// Toplam bir il veya ilcenin sandik sonuclarinin toplami. Oylar baslik
+// adiyla tutulur; column name'ler secim cevreleri arasinda degisir.
+type Toplam struct {
+	IlID       int
+	IlAdi      string
+	IlceID     int
+	IlceAdi    string
+	Sandik     int
+	Secmen     int
+	OyKullanan int
+	Gecerli    int
+	Gecersiz   int
+	Oylar      map[string]int
+	// adaylar kazanan hesabina giren parti / aday adlari; ittifak oylari
+	// partilerle ust uste bindigi icin disarida kalir
+	adaylar map[string]bool
+}
+
+func newToplam(ilID int, ilAdi string, ilceID int, ilceAdi string) *Toplam {
+	return &Toplam{IlID: ilID, IlAdi: ilAdi, IlceID: ilceID, IlceAdi: ilceAdi,
+		Oylar: make(map[string]int), adaylar: make(map[string]bool)}
+}
+
+func (t *Toplam) ekle(s src.SandikSonuc, sutunlar map[string]src.SecimSonucBaslik) {
+	t.Sandik++
+	t.Secmen += s.SecmenSAYISI
+	t.OyKullanan += s.OyKULLANANSECMENSAYISI
+	t.Gecerli += s.GecerliOYTOPLAMI
+	t.Gecersiz += s.GecersizOYTOPLAMI
+	for col, oy := range s.Votes {
+		ad := col
+		if b, ok := sutunlar[col]; ok {
+			ad = b.Ad
+		}
+		t.Oylar[ad] += oy
+		if registry.TurOf(col) != registry.Ittifak {
+			t.adaylar[ad] = true
+		}
+	}
+}
+
+// Katilim oy kullanan / secmen; secmen yoksa 0
+func (t *Toplam) Katilim() float64 {
+	if t.Secmen == 0 {
+		return 0
+	}
+	return float64(t.OyKullanan) / float64(t.Secmen)
+}
+
+// Pay bir adin gecerli oylar icindeki payi
+func (t *Toplam) Pay(ad string) float64 {
+	if t.Gecerli == 0 {
+		return 0
+	}
+	return float64(t.Oylar[ad]) / float64(t.Gecerli)
+}
+
+// Kazanan en cok oyu alan parti / aday; esitlikte ad sirasi belirler
+func (t *Toplam) Kazanan() (string, int) {
+	var kazanan string
+	enCok := -1
+	for ad := range t.adaylar {
+		if oy := t.Oylar[ad]; oy > enCok || (oy == enCok && ad < kazanan) {
+			kazanan, enCok = ad, oy
+		}
+	}
+	if enCok <= 0 {
+		return "", 0
+	}
+	return kazanan, enCok
+}
+
+// Toplayici sandik sonuclarini il ve ilce bazinda toplar. Eszamanli kullanima uygundur.
+type Toplayici struct {
+	mu   sync.Mutex
+	il   map[int]*Toplam
+	ilce map[int]*Toplam
+}
+
+func New() *Toplayici {
+	return &Toplayici{il: make(map[int]*Toplam), ilce: make(map[int]*Toplam)}
+}
+
+// Ekle ilcenin bir sandigini toplamlara ekler. Id ve adlar sandik
+// satirindan, bos gelirse sandigin cekildigi ilceden alinir.
+func (t *Toplayici) Ekle(ic src.Ilce, s src.SandikSonuc, sutunlar map[string]src.SecimSonucBaslik) {
+	ilID, ilceID := s.IlID, s.IlceID
+	if ilID == 0 {
+		ilID = ic.IlID
+	}
+	if ilceID == 0 {
+		ilceID = ic.IlceID
+	}
+	ilAdi, ilceAdi := geo.IlAdi(s.IlADI), strings.TrimSpace(s.IlceADI)
+	if ilAdi == "" {
+		ilAdi = geo.IlAdi(ic.IlADI)
+	}
+	if ilceAdi == "" {
+		ilceAdi = ic.IlceADI
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	il, ok := t.il[ilID]
+	if !ok {
+		il = newToplam(ilID, ilAdi, 0, "")
+		t.il[ilID] = il
+	}
+	il.ekle(s, sutunlar)
+	ilce, ok := t.ilce[ilceID]
+	if !ok {
+		ilce = newToplam(ilID, ilAdi, ilceID, ilceAdi)
+		t.ilce[ilceID] = ilce
+	}
+	ilce.ekle(s, sutunlar)
+}
+
+// Toplamlar seviyenin toplamlarini id sirasiyla doner
+func (t *Toplayici) Toplamlar(sv Seviye) []*Toplam {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	m := t.il
+	if sv == IlceSeviyesi {
+		m = t.ilce
+	}
+	l := make([]*Toplam, 0, len(m))
+	for _, tp := range m {
+		l = append(l, tp)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].IlID != l[j].IlID {
+			return l[i].IlID < l[j].IlID
+		}
+		return l[i].IlceID < l[j].IlceID
+	})
+	return l
+}
+
+// endregion
+// region GeoJSON
+
+// FeatureCollection kullanicinin verdigi sinir dosyasi. Geometriler
+// okunmadan oldugu gibi yazilir.
+type FeatureCollection struct {
+	Type     string          `json:"type"`
+	BBox     json.RawMessage `json:"bbox,omitempty"`
+	CRS      json.RawMessage `json:"crs,omitempty"`
+	Features []Feature       `json:"features"`
+}
+
+type Feature struct {
+	Type       string          `json:"type"`
+	ID         json.RawMessage `json:"id,omitempty"`
+	Properties map[string]any  `json:"properties"`
+	Geometry   json.RawMessage `json:"geometry"`
+}
+
+// Oku GeoJSON FeatureCollection okur
+func Oku(r io.Reader) (*FeatureCollection, error) {
+	var fc FeatureCollection
+	if err := json.NewDecoder(r).Decode(&fc); err != nil {
+		return nil, fmt.Errorf("cannot decode geojson: %w", err)
+	}
+	if fc.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("expected a FeatureCollection, got %q", fc.Type)
+	}
+	return &fc, nil
+}
+
+func (fc *FeatureCollection) Yaz(w io.Writer) error {
+	return json.NewEncoder(w).Encode(fc)
+}
+
+// endregion
+// region Eslestirme
+
+// harfler Turkce harfleri ascii karsiliklarina cevirir; sapkali harfler dahil
+var harfler = strings.NewReplacer("Ç", "C", "Ğ", "G", "İ", "I", "Ö", "O", "Ş", "S", "Ü", "U",
+	"Â", "A", "Î", "I", "Û", "U")
+
+// Normalize adi Turkce buyuk harfe cevirip harf ve rakam disindaki her seyi
+// atar: "Iğdır", "IĞDIR" ve "igdir" hepsi "IGDIR" olur.
+func Normalize(ad string) string {
+	s := harfler.Replace(strings.ToUpperSpecial(unicode.TurkishCase, ad))
+	return strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// Ayar sinirlarin toplamlarla nasil eslenecegi
+type Ayar struct {
+	Seviye Seviye
+	// IDAlani kokpit il / ilce id'sini tutan ozellik; bos veya eksikse ada bakilir
+	IDAlani string
+	// AdAlani il / ilce adini tutan ozellik
+	AdAlani string
+	// IlAdAlani ilce sinirlarinda il adini tutan ozellik; "MERKEZ" gibi
+	// birden fazla ilde olan ilce adlarini ayirmak icin
+	IlAdAlani string
+}
+
+// Rapor eslesmeyen sinirlar ve hicbir sinira eslenmeyen toplamlar
+type Rapor struct {
+	Eslesen       int
+	Eslesmeyen    []string
+	Kullanilmayan []*Toplam
+}
+
+// Birlestir her sinira eslesen toplamin ozelliklerini ekler. Eslesmeyen
+// sinirlar dosyada kalir, sadece "eslesti": false alir.
+func Birlestir(fc *FeatureCollection, t *Toplayici, a Ayar) Rapor {
+	toplamlar := t.Toplamlar(a.Seviye)
+	idIle := make(map[int]*Toplam)
+	adIle := make(map[string][]*Toplam)
+	for _, tp := range toplamlar {
+		id, ad := tp.IlID, Normalize(tp.IlAdi)
+		if a.Seviye == IlceSeviyesi {
+			id = tp.IlceID
+			adIle[Normalize(tp.IlceAdi)] = append(adIle[Normalize(tp.IlceAdi)], tp)
+			ad += "/" + Normalize(tp.IlceAdi)
+		}
+		idIle[id] = tp
+		adIle[ad] = append(adIle[ad], tp)
+	}
+
+	var r Rapor
+	kullanilan := make(map[*Toplam]bool)
+	for i := range fc.Features {
+		f := &fc.Features[i]
+		if f.Properties == nil {
+			f.Properties = make(map[string]any)
+		}
+		tp, neden := a.bul(f.Properties, idIle, adIle)
+		if tp == nil {
+			f.Properties["eslesti"] = false
+			r.Eslesmeyen = append(r.Eslesmeyen, fmt.Sprintf("%d: %s", i, neden))
+			continue
+		}
+		r.Eslesen++
+		kullanilan[tp] = true
+		ozellikler(f.Properties, tp, a.Seviye)
+	}
+	for _, tp := range toplamlar {
+		if !kullanilan[tp] {
+			r.Kullanilmayan = append(r.Kullanilmayan, tp)
+		}
+	}
+	return r
+}
+
+// bul sinirin toplamini id'yle, bulamazsa adla arar; bulamazsa nedenini doner
+func (a Ayar) bul(p map[string]any, idIle map[int]*Toplam, adIle map[string][]*Toplam) (*Toplam, string) {
+	if a.IDAlani != "" {
+		if id, ok := ozellikSayi(p[a.IDAlani]); ok {
+			if tp, ok := idIle[id]; ok {
+				return tp, ""
+			}
+		}
+	}
+	ad, _ := p[a.AdAlani].(string)
+	if Normalize(ad) == "" {
+		return nil, fmt.Sprintf("no %q property", a.AdAlani)
+	}
+	anahtar := Normalize(ad)
+	// il adi olmayan sinirlar sadece ilce adiyla, tek ilceye uyarsa eslenir
+	if il, _ := p[a.IlAdAlani].(string); a.Seviye == IlceSeviyesi && a.IlAdAlani != "" && Normalize(il) != "" {
+		anahtar = Normalize(il) + "/" + anahtar
+	}
+	switch l := adIle[anahtar]; len(l) {
+	case 0:
+		return nil, fmt.Sprintf("no results for %q", ad)
+	case 1:
+		return l[0], ""
+	default:
+		return nil, fmt.Sprintf("%q matches %d units", ad, len(l))
+	}
+}
+
+func ozellikSayi(v any) (int, bool) {
+	switch x := v.(type) {
+	case float64:
+		return int(x), true
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(x))
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// yuvarla paylari dosya boyutu icin 4 haneye yuvarlar
+func yuvarla(f float64) float64 {
+	return math.Round(f*1e4) / 1e4
+}
+
+func ozellikler(p map[string]any, tp *Toplam, sv Seviye) {
+	p["eslesti"] = true
+	p["kokpit_il_id"] = tp.IlID
+	p["kokpit_il_adi"] = tp.IlAdi
+	if sv == IlceSeviyesi {
+		p["kokpit_ilce_id"] = tp.IlceID
+		p["kokpit_ilce_adi"] = tp.IlceAdi
+	}
+	p["sandik_sayisi"] = tp.Sandik
+	p["secmen_sayisi"] = tp.Secmen
+	p["oy_kullanan"] = tp.OyKullanan
+	p["gecerli_oy"] = tp.Gecerli
+	p["gecersiz_oy"] = tp.Gecersiz
+	p["katilim"] = yuvarla(tp.Katilim())
+	paylar := make(map[string]float64, len(tp.Oylar))
+	for ad := range tp.Oylar {
+		paylar[ad] = yuvarla(tp.Pay(ad))
+	}
+	p["oylar"] = tp.Oylar
+	p["paylar"] = paylar
+	kazanan, oy := tp.Kazanan()
+	p["kazanan"] = kazanan
+	p["kazanan_oy"] = oy
+	p["kazanan_pay"] = yuvarla(tp.Pay(kazanan))
+}
+
+// endregion
@@ -0,0 +1,64 @@
+package harita
+
+import (
+	"github.com/secim/src"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	for _, ad := range []string{"IĞDIR", "Iğdır", "igdir", " Iğdır "} {
+		if got := Normalize(ad); got != "IGDIR" {
+			t.Errorf("Normalize(%q) = %q", ad, got)
+		}
+	}
+	if Normalize("İSTANBUL") != Normalize("Istanbul") || Normalize("istanbul") != "ISTANBUL" {
+		t.Error("istanbul spellings differ")
+	}
+}
+
+func TestBirlestir(t *testing.T) {
+	sutunlar := map[string]src.SecimSonucBaslik{
+		"ittifak_1": {Ad: "CUMHUR İTTİFAKI"}, "parti_1": {Ad: "A PARTİSİ"}, "parti_2": {Ad: "B PARTİSİ"},
+	}
+	sandik := func(ilceID int, ilce string, oylar ...int) src.SandikSonuc {
+		return src.SandikSonuc{IlID: 6, IlADI: "ANKARA", IlceID: ilceID, IlceADI: ilce,
+			SecmenSAYISI: 100, OyKULLANANSECMENSAYISI: 80, GecerliOYTOPLAMI: oylar[1] + oylar[2],
+			Votes: map[string]int{"ittifak_1": oylar[0], "parti_1": oylar[1], "parti_2": oylar[2]}}
+	}
+	top := New()
+	top.Ekle(src.Ilce{}, sandik(815, "ÇANKAYA", 500, 30, 40), sutunlar)
+	top.Ekle(src.Ilce{}, sandik(815, "ÇANKAYA", 500, 20, 50), sutunlar)
+	top.Ekle(src.Ilce{}, sandik(999, "MERKEZ", 500, 60, 10), sutunlar)
+	top.Ekle(src.Ilce{IlID: 7, IlADI: "ANTALYA 1"}, src.SandikSonuc{IlceID: 1000, IlceADI: "MERKEZ"}, sutunlar)
+
+	fc := &FeatureCollection{Type: "FeatureCollection", Features: []Feature{
+		{Type: "Feature", Properties: map[string]any{"ad": "Çankaya", "il": "Ankara"}},
+		{Type: "Feature", Properties: map[string]any{"ad": "Merkez"}},
+		{Type: "Feature", Properties: map[string]any{"ad": "Merkez", "il": "antalya"}},
+		{Type: "Feature", Properties: map[string]any{"kod": 999.0}},
+	}}
+	r := Birlestir(fc, top, Ayar{Seviye: IlceSeviyesi, IDAlani: "kod", AdAlani: "ad", IlAdAlani: "il"})
+	if r.Eslesen != 3 || len(r.Eslesmeyen) != 1 || len(r.Kullanilmayan) != 0 {
+		t.Fatalf("unexpected report %+v", r)
+	}
+	// il adi olmadan MERKEZ iki ilceye uyar
+	if !strings.Contains(r.Eslesmeyen[0], "matches 2 units") {
+		t.Errorf("unexpected unmatched reason %q", r.Eslesmeyen[0])
+	}
+	p := fc.Features[0].Properties
+	if p["kazanan"] != "B PARTİSİ" || p["kazanan_oy"] != 90 || p["kazanan_pay"] != 0.6429 {
+		t.Errorf("unexpected winner %v %v %v", p["kazanan"], p["kazanan_oy"], p["kazanan_pay"])
+	}
+	if p["katilim"] != 0.8 || p["sandik_sayisi"] != 2 || p["kokpit_ilce_id"] != 815 {
+		t.Errorf("unexpected properties %v", p)
+	}
+	if fc.Features[2].Properties["kokpit_il_adi"] != "ANTALYA" || fc.Features[3].Properties["kokpit_ilce_adi"] != "MERKEZ" {
+		t.Error("antalya / id match missing")
+	}
+
+	il := top.Toplamlar(IlSeviyesi)
+	if len(il) != 2 || il[0].Oylar["A PARTİSİ"] != 110 || il[0].Sandik != 3 {
+		t.Errorf("unexpected il totals %+v", il[0])
+	}
+}
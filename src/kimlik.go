@@ -0,0 +1,112 @@
+package src
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// region SandikKimligi
+
+// SandikKimligi bir sandigin kararli kimligi: secim, kapsam (sandikTuru),
+// sandigin cekildigi birimin id'leri ve sandik no / rumuz / id. Ciktilardaki
+// "#" sira numarasinin aksine ayni sandik her calismada ayni anahtari alir.
+type SandikKimligi struct {
+	SecimID          int
+	SecimTuru        int
+	SandikTuru       int
+	IlID             int
+	IlceID           int
+	UlkeID           int
+	DisTemsilcilikID int
+	GumrukID         int
+	SandikNO         int
+	SandikRUMUZ      string
+	SandikID         int
+	// Sira sandik no, rumuz ve id'si gelmeyen satirin sorgu sonucundaki
+	// sirasi (1'den baslar); bunlar olmadan birimin satirlari ayni anahtari alirdi
+	Sira int
+}
+
+// Kimlik sorguyla cekilmis bir satirin kimligini doner. Birim id'leri
+// sorgudan, sandik no / rumuz / id satirdan alinir; sira satirin sonuc
+// listesindeki indeksidir ve yalnizca bunlar bossa kullanilir.
+func (q SandikSonucQuery) Kimlik(row map[string]any, sira int) SandikKimligi {
+	k := SandikKimligi{
+		SecimID: q.SecimID, SecimTuru: q.SecimTuru, SandikTuru: q.SandikTuru,
+		IlID: q.IlID, IlceID: q.IlceID,
+		UlkeID: q.UlkeID, DisTemsilcilikID: q.DisTemsilcilikID, GumrukID: q.GumrukID,
+	}
+	k.SandikNO, _ = satirSayi(row[KeySandikNO])
+	k.SandikID, _ = satirSayi(row[KeySandikID])
+	if r, ok := row[KeySandikRUMUZ].(string); ok {
+		k.SandikRUMUZ = strings.TrimSpace(r)
+	}
+	if k.SandikNO == 0 && k.SandikID == 0 && k.SandikRUMUZ == "" {
+		k.Sira = sira + 1
+	}
+	return k
+}
+
+// String kimligi csv'ye yazilabilir tek bir anahtara cevirir:
+//
+//	60792/8/0/il6.ilce815/no1001.id123456
+//	60792/9/3/ulke9988.dt14/rumuz1001A
+//	60792/8/0/il6.ilce815/sira3
+func (k SandikKimligi) String() string {
+	return fmt.Sprintf("%d/%d/%d/%s/%s", k.SecimID, k.SecimTuru, k.SandikTuru,
+		k.birim(k.IlID), k.sandik(true))
+}
+
+// Tekil farkli sorgularda (ornek: cezaevi sandiklarinin ilce listesinde de
+// gelmesi) ayni fiziksel sandigi tanimak icin kullanilir. Api'nin sandik
+// id'si varsa kapsamdan ve birimden bagimsizdir. Yoksa sandikTuru ve il
+// atilir; sandik nolari ilce icinde tekil oldugu icin ilce ve no / rumuz yeter.
+// Sirayla tanimlanan satirlar baska bir sorgudaki satirla eslestirilemez;
+// onlarin tekil kimligi String'tir:
+//
+//	60792/8/id123456
+//	60792/8/ilce815/no1001
+func (k SandikKimligi) Tekil() string {
+	if k.Sira != 0 {
+		return k.String()
+	}
+	if k.SandikID != 0 {
+		return fmt.Sprintf("%d/%d/id%d", k.SecimID, k.SecimTuru, k.SandikID)
+	}
+	return fmt.Sprintf("%d/%d/%s/%s", k.SecimID, k.SecimTuru, k.birim(0), k.sandik(false))
+}
+
+// birim sandigin cekildigi birimin id'leri; il 0 verilirse yazilmaz
+func (k SandikKimligi) birim(il int) string {
+	var l []string
+	for _, p := range []struct {
+		ad string
+		id int
+	}{{"il", il}, {"ilce", k.IlceID}, {"ulke", k.UlkeID}, {"dt", k.DisTemsilcilikID}, {"gumruk", k.GumrukID}} {
+		if p.id != 0 {
+			l = append(l, fmt.Sprintf("%s%d", p.ad, p.id))
+		}
+	}
+	return strings.Join(l, ".")
+}
+
+// sandik sandik no ve rumuzu, istenirse sandik id'siyle birlikte yazar
+func (k SandikKimligi) sandik(id bool) string {
+	var l []string
+	if k.SandikNO != 0 {
+		l = append(l, fmt.Sprintf("no%d", k.SandikNO))
+	}
+	if k.SandikRUMUZ != "" {
+		l = append(l, "rumuz"+url.PathEscape(k.SandikRUMUZ))
+	}
+	if id && k.SandikID != 0 {
+		l = append(l, fmt.Sprintf("id%d", k.SandikID))
+	}
+	if k.Sira != 0 {
+		l = append(l, fmt.Sprintf("sira%d", k.Sira))
+	}
+	return strings.Join(l, ".")
+}
+
+// endregion
@@ -0,0 +1,66 @@
+package src
+
+import "testing"
+
+func TestSandikKimligi(t *testing.T) {
+	ilce := Ilce{IlID: 6, IlceID: 815, SecimCEVRESIID: 404520}
+	row := map[string]any{KeySandikNO: "1001", KeySandikID: 123456.0, KeySandikRUMUZ: nil}
+	k := IlceSonucParams(ilce, 8).Kimlik(row, 0)
+	if got, want := k.String(), "60792/8/0/il6.ilce815/no1001.id123456"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	// ayni sandik cezaevi sorgusunda da gelirse tekil kimlik ayni olmali
+	kc := CezaeviSonucParams(ilce, 8).Kimlik(row, 0)
+	if kc.String() == k.String() {
+		t.Errorf("different scopes share key %s", k)
+	}
+	if kc.Tekil() != k.Tekil() {
+		t.Errorf("Tekil() differs: %s, %s", kc.Tekil(), k.Tekil())
+	}
+	if k.Tekil() == IlceSonucParams(ilce, 9).Kimlik(row, 0).Tekil() {
+		t.Error("cb and mv results share an identity")
+	}
+
+	dt := DisTemsSonucParams(DisTemsilcilik{UlkeID: 9988, DisTEMSILCILIKID: 14}, 9).
+		Kimlik(map[string]any{KeySandikRUMUZ: "1001 A"}, 0)
+	if got, want := dt.String(), "60792/9/3/ulke9988.dt14/rumuz1001%20A"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if got, want := dt.Tekil(), "60792/9/ulke9988.dt14/rumuz1001%20A"; got != want {
+		t.Errorf("Tekil() without sandik id = %s, want %s", got, want)
+	}
+
+	// sandik id'si gelmeyen cezaevi sandigi ilce listesinde de gelirse yakalanmali
+	idsiz := map[string]any{KeySandikNO: 1001.0}
+	ki, kc := IlceSonucParams(ilce, 8).Kimlik(idsiz, 0), CezaeviSonucParams(ilce, 8).Kimlik(idsiz, 0)
+	if ki.Tekil() != kc.Tekil() || ki.Tekil() != "60792/8/ilce815/no1001" {
+		t.Errorf("Tekil() without sandik id: %s, %s", ki.Tekil(), kc.Tekil())
+	}
+	if ki.String() == kc.String() {
+		t.Errorf("different scopes share key %s", ki)
+	}
+	if ki.Tekil() == IlceSonucParams(ilce, 8).Kimlik(map[string]any{KeySandikNO: 1002.0}, 1).Tekil() {
+		t.Error("different boxes share an identity")
+	}
+}
+
+func TestSandikKimligiSirali(t *testing.T) {
+	ilce := Ilce{IlID: 6, IlceID: 815, SecimCEVRESIID: 404520}
+	// sandik no, rumuz ve id'si olmayan satirlar sirayla ayrilir
+	bos := map[string]any{KeySandikNO: nil, KeySandikID: 0.0, KeySandikRUMUZ: " "}
+	k0, k1 := IlceSonucParams(ilce, 8).Kimlik(bos, 0), IlceSonucParams(ilce, 8).Kimlik(bos, 1)
+	if got, want := k1.String(), "60792/8/0/il6.ilce815/sira2"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if k0.String() == k1.String() || k0.Tekil() == k1.Tekil() {
+		t.Errorf("rows share a key: %s, %s", k0.Tekil(), k1.Tekil())
+	}
+	// baska bir sorgunun ayni sirasi ayni sandik degildir
+	if kc := CezaeviSonucParams(ilce, 8).Kimlik(bos, 1); kc.Tekil() == k1.Tekil() {
+		t.Errorf("scopes share key %s", kc.Tekil())
+	}
+	// kimligi olan satirda sira yazilmaz
+	if k := IlceSonucParams(ilce, 8).Kimlik(map[string]any{KeySandikNO: 7.0}, 4); k.Sira != 0 || k.String() != "60792/8/0/il6.ilce815/no7" {
+		t.Errorf("got %+v", k)
+	}
+}
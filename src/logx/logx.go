@@ -0,0 +1,246 @@
+package logx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// region Level
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	}
+	return "error"
+}
+
+func ParseLevel(s string) (Level, error) {
+	for l := LevelDebug; l <= LevelError; l++ {
+		if strings.EqualFold(s, l.String()) {
+			return l, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level: %q", s)
+}
+
+// endregion
+// region Format
+
+type Format int
+
+const (
+	// FormatText is a human readable line with trailing key=value fields.
+	FormatText Format = iota
+	FormatLogfmt
+	FormatJSON
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "text":
+		return FormatText, nil
+	case "logfmt":
+		return FormatLogfmt, nil
+	case "json":
+		return FormatJSON, nil
+	}
+	return 0, fmt.Errorf("unknown log format: %q", s)
+}
+
+// endregion
+// region Logger
+
+// Field is a structured key / value pair attached to a log line.
+type Field struct {
+	Key   string
+	Value any
+}
+
+func F(key string, value any) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Err is shorthand for the conventional error field.
+func Err(err error) Field {
+	return Field{Key: "err", Value: err}
+}
+
+// Logger writes leveled, structured lines. Loggers derived with With share
+// the output and its lock, so lines from concurrent goroutines never interleave.
+type Logger struct {
+	out    *output
+	level  Level
+	format Format
+	fields []Field
+}
+
+type output struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func New(w io.Writer, level Level, format Format) *Logger {
+	return &Logger{out: &output{w: w}, level: level, format: format}
+}
+
+// With returns a logger that adds fields to every line.
+func (l *Logger) With(fields ...Field) *Logger {
+	n := *l
+	n.fields = append(append([]Field(nil), l.fields...), fields...)
+	return &n
+}
+
+// WithOutput returns a logger with the same level, format and fields that
+// writes to w.
+func (l *Logger) WithOutput(w io.Writer) *Logger {
+	n := *l
+	n.out = &output{w: w}
+	return &n
+}
+
+// Enabled reports whether lines at level are written.
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.level
+}
+
+// Writer is the underlying output. Writing to it bypasses the lock and the
+// format; log reports as records instead.
+func (l *Logger) Writer() io.Writer {
+	return l.out.w
+}
+
+func (l *Logger) Debug(msg string, fields ...Field) { l.log(LevelDebug, msg, fields) }
+func (l *Logger) Info(msg string, fields ...Field)  { l.log(LevelInfo, msg, fields) }
+func (l *Logger) Warn(msg string, fields ...Field)  { l.log(LevelWarn, msg, fields) }
+func (l *Logger) Error(msg string, fields ...Field) { l.log(LevelError, msg, fields) }
+
+// Fatal logs at error level and exits with status 1 through the function
+// set with SetExitFunc.
+func (l *Logger) Fatal(msg string, fields ...Field) {
+	l.log(LevelError, msg, fields)
+	defMu.RLock()
+	exit := exitFunc
+	defMu.RUnlock()
+	exit(1)
+	// in case exit returned
+	os.Exit(1)
+}
+
+func (l *Logger) log(level Level, msg string, fields []Field) {
+	if !l.Enabled(level) {
+		return
+	}
+	all := append(append(make([]Field, 0, len(l.fields)+len(fields)), l.fields...), fields...)
+	line := l.format.line(time.Now(), level, msg, all)
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	_, _ = io.WriteString(l.out.w, line)
+}
+
+func (f Format) line(t time.Time, level Level, msg string, fields []Field) string {
+	var b strings.Builder
+	switch f {
+	case FormatJSON:
+		m := make(map[string]any, len(fields)+3)
+		for _, fl := range fields {
+			m[fl.Key] = jsonValue(fl.Value)
+		}
+		m["time"], m["level"], m["msg"] = t.Format(time.RFC3339), level.String(), msg
+		buf, err := json.Marshal(m)
+		if err != nil {
+			buf, _ = json.Marshal(map[string]string{"time": t.Format(time.RFC3339), "level": "error",
+				"msg": "cannot encode log line", "err": err.Error(), "orig": msg})
+		}
+		b.Write(buf)
+	case FormatLogfmt:
+		b.WriteString("time=" + t.Format(time.RFC3339) + " level=" + level.String() + " msg=" + logfmtValue(msg))
+		for _, fl := range fields {
+			b.WriteString(" " + fl.Key + "=" + logfmtValue(fmt.Sprint(fl.Value)))
+		}
+	default:
+		fmt.Fprintf(&b, "%s %-5s %s", t.Format("2006/01/02 15:04:05"), strings.ToUpper(level.String()), msg)
+		for _, fl := range fields {
+			b.WriteString(" " + fl.Key + "=" + logfmtValue(fmt.Sprint(fl.Value)))
+		}
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+// errors marshal to {} in JSON; use their message instead
+func jsonValue(v any) any {
+	switch x := v.(type) {
+	case error:
+		return x.Error()
+	case fmt.Stringer:
+		return x.String()
+	}
+	return v
+}
+
+func logfmtValue(s string) string {
+	if s == "" || strings.ContainsAny(s, " =\"\t\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// endregion
+// region Default
+
+var (
+	defMu    sync.RWMutex
+	def      = New(os.Stderr, LevelInfo, FormatText)
+	exitFunc = os.Exit
+)
+
+// Default returns the process wide logger.
+func Default() *Logger {
+	defMu.RLock()
+	defer defMu.RUnlock()
+	return def
+}
+
+func SetDefault(l *Logger) {
+	defMu.Lock()
+	defer defMu.Unlock()
+	def = l
+}
+
+// SetExitFunc replaces os.Exit in Fatal so the program can write its reports
+// before exiting. fn should exit with the given code; Fatal exits if it returns.
+func SetExitFunc(fn func(code int)) {
+	defMu.Lock()
+	defer defMu.Unlock()
+	exitFunc = fn
+}
+
+func With(fields ...Field) *Logger      { return Default().With(fields...) }
+func Debug(msg string, fields ...Field) { Default().log(LevelDebug, msg, fields) }
+func Info(msg string, fields ...Field)  { Default().log(LevelInfo, msg, fields) }
+func Warn(msg string, fields ...Field)  { Default().log(LevelWarn, msg, fields) }
+func Error(msg string, fields ...Field) { Default().log(LevelError, msg, fields) }
+func Fatal(msg string, fields ...Field) { Default().Fatal(msg, fields...) }
+func Enabled(level Level) bool          { return Default().Enabled(level) }
+
+// endregion
@@ -0,0 +1,395 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// region Registry
+
+// metric is implemented by every collector that can be written out.
+type metric interface {
+	name() string
+	writeProm(w io.Writer) error
+	summary() []Sample
+}
+
+// Sample is one entry of the run summary: a metric for one label
+// combination. Histograms report the observation count and their mean as
+// Value.
+type Sample struct {
+	Name   string
+	Type   string
+	Labels []Label
+	Value  float64
+	Count  uint64
+}
+
+// Label is a label name and value of a Sample, in registration order.
+type Label struct {
+	Name, Value string
+}
+
+// Registry holds a set of metrics in registration order.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+	names   map[string]bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// Default is the registry used by the package level constructors.
+var Default = NewRegistry()
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[m.name()] {
+		panic("metrics: duplicate metric " + m.name())
+	}
+	r.names[m.name()] = true
+	r.metrics = append(r.metrics, m)
+}
+
+func (r *Registry) list() []metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]metric(nil), r.metrics...)
+}
+
+// WritePrometheus writes all metrics in the Prometheus text exposition format.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	for _, m := range r.list() {
+		if err := m.writeProm(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Summary lists every metric and label combination in registration and
+// label order, for reporting through a structured logger.
+func (r *Registry) Summary() []Sample {
+	var l []Sample
+	for _, m := range r.list() {
+		l = append(l, m.summary()...)
+	}
+	return l
+}
+
+// Handler serves the registry on a /metrics style endpoint.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = r.WritePrometheus(w)
+	})
+}
+
+// endregion
+// region vec
+
+// vec stores one value per label combination.
+type vec[V any] struct {
+	n, help, typ string
+	labels       []string
+	mu           sync.Mutex
+	vals         map[string]*V
+	keys         map[string][]string
+	newV         func() *V
+}
+
+func newVec[V any](name, help, typ string, labels []string, newV func() *V) *vec[V] {
+	return &vec[V]{
+		n: name, help: help, typ: typ, labels: labels, newV: newV,
+		vals: make(map[string]*V), keys: make(map[string][]string),
+	}
+}
+
+func (v *vec[V]) name() string { return v.n }
+
+// with runs fn on the value for the label values under the vec lock.
+func (v *vec[V]) with(lvs []string, fn func(*V)) {
+	if len(lvs) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.n, len(v.labels), len(lvs)))
+	}
+	k := strings.Join(lvs, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	val, ok := v.vals[k]
+	if !ok {
+		val = v.newV()
+		v.vals[k] = val
+		v.keys[k] = append([]string(nil), lvs...)
+	}
+	fn(val)
+}
+
+// each calls fn for every label combination in sorted order under the lock.
+func (v *vec[V]) each(fn func(lvs []string, val *V) error) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	ks := make([]string, 0, len(v.vals))
+	for k := range v.vals {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	for _, k := range ks {
+		if err := fn(v.keys[k], v.vals[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *vec[V]) writeHeader(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.n, helpEscaper.Replace(v.help), v.n, v.typ)
+	return err
+}
+
+// The exposition format only escapes backslash and newline in HELP text and
+// additionally the double quote in label values; %q would also produce Go
+// escapes such as \t or \x00 that Prometheus rejects.
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// sample builds the Sample of one label combination.
+func (v *vec[V]) sample(lvs []string, value float64) Sample {
+	s := Sample{Name: v.n, Type: v.typ, Value: value}
+	for i, n := range v.labels {
+		s.Labels = append(s.Labels, Label{Name: n, Value: lvs[i]})
+	}
+	return s
+}
+
+func labelString(names, vals []string, extra ...string) string {
+	parts := make([]string, 0, len(names)+1)
+	for i, n := range names {
+		parts = append(parts, n+`="`+labelEscaper.Replace(vals[i])+`"`)
+	}
+	parts = append(parts, extra...)
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// endregion
+// region Counter
+
+// Counter is a monotonically increasing value per label combination.
+type Counter struct {
+	*vec[float64]
+}
+
+func NewCounter(name, help string, labels ...string) *Counter {
+	return Default.NewCounter(name, help, labels...)
+}
+
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{newVec(name, help, "counter", labels, func() *float64 { return new(float64) })}
+	r.register(c)
+	return c
+}
+
+func (c *Counter) Add(d float64, lvs ...string) {
+	c.with(lvs, func(v *float64) { *v += d })
+}
+
+func (c *Counter) Inc(lvs ...string) {
+	c.Add(1, lvs...)
+}
+
+// Total sums the counter over all label combinations.
+func (c *Counter) Total() (t float64) {
+	_ = c.each(func(_ []string, v *float64) error {
+		t += *v
+		return nil
+	})
+	return
+}
+
+func (c *Counter) writeProm(w io.Writer) error {
+	if err := c.writeHeader(w); err != nil {
+		return err
+	}
+	return c.each(func(lvs []string, v *float64) error {
+		_, err := fmt.Fprintf(w, "%s%s %s\n", c.n, labelString(c.labels, lvs), formatFloat(*v))
+		return err
+	})
+}
+
+func (c *Counter) summary() (l []Sample) {
+	_ = c.each(func(lvs []string, v *float64) error {
+		l = append(l, c.sample(lvs, *v))
+		return nil
+	})
+	return
+}
+
+// endregion
+// region Gauge
+
+// Gauge is a value that can go up and down per label combination.
+type Gauge struct {
+	*vec[float64]
+}
+
+func NewGauge(name, help string, labels ...string) *Gauge {
+	return Default.NewGauge(name, help, labels...)
+}
+
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{newVec(name, help, "gauge", labels, func() *float64 { return new(float64) })}
+	r.register(g)
+	return g
+}
+
+func (g *Gauge) Set(x float64, lvs ...string) {
+	g.with(lvs, func(v *float64) { *v = x })
+}
+
+func (g *Gauge) Add(d float64, lvs ...string) {
+	g.with(lvs, func(v *float64) { *v += d })
+}
+
+func (g *Gauge) writeProm(w io.Writer) error {
+	if err := g.writeHeader(w); err != nil {
+		return err
+	}
+	return g.each(func(lvs []string, v *float64) error {
+		_, err := fmt.Fprintf(w, "%s%s %s\n", g.n, labelString(g.labels, lvs), formatFloat(*v))
+		return err
+	})
+}
+
+func (g *Gauge) summary() (l []Sample) {
+	_ = g.each(func(lvs []string, v *float64) error {
+		l = append(l, g.sample(lvs, *v))
+		return nil
+	})
+	return
+}
+
+// GaugeFunc is an unlabeled gauge whose value is computed on every scrape.
+type GaugeFunc struct {
+	n, help string
+	fn      func() float64
+}
+
+func NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	return Default.NewGaugeFunc(name, help, fn)
+}
+
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	g := &GaugeFunc{n: name, help: help, fn: fn}
+	r.register(g)
+	return g
+}
+
+func (g *GaugeFunc) name() string { return g.n }
+
+func (g *GaugeFunc) writeProm(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", g.n, helpEscaper.Replace(g.help), g.n, g.n,
+		formatFloat(g.fn()))
+	return err
+}
+
+func (g *GaugeFunc) summary() []Sample {
+	return []Sample{{Name: g.n, Type: "gauge", Value: g.fn()}}
+}
+
+// endregion
+// region Histogram
+
+// DefBuckets are latency buckets in seconds suited to the kokpit API.
+var DefBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type histValue struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Histogram counts observations into cumulative buckets per label combination.
+type Histogram struct {
+	*vec[histValue]
+	buckets []float64
+}
+
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return Default.NewHistogram(name, help, buckets, labels...)
+}
+
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	b := append(append([]float64(nil), buckets...), math.Inf(1))
+	sort.Float64s(b)
+	h := &Histogram{buckets: b}
+	h.vec = newVec(name, help, "histogram", labels, func() *histValue {
+		return &histValue{counts: make([]uint64, len(b))}
+	})
+	r.register(h)
+	return h
+}
+
+func (h *Histogram) Observe(x float64, lvs ...string) {
+	h.with(lvs, func(v *histValue) {
+		for i, ub := range h.buckets {
+			if x <= ub {
+				v.counts[i]++
+			}
+		}
+		v.sum += x
+		v.count++
+	})
+}
+
+func (h *Histogram) writeProm(w io.Writer) error {
+	if err := h.writeHeader(w); err != nil {
+		return err
+	}
+	return h.each(func(lvs []string, v *histValue) error {
+		for i, ub := range h.buckets {
+			le := `le="` + formatFloat(ub) + `"`
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.n, labelString(h.labels, lvs, le), v.counts[i]); err != nil {
+				return err
+			}
+		}
+		_, err := fmt.Fprintf(w, "%s_sum%s %s\n%s_count%s %d\n",
+			h.n, labelString(h.labels, lvs), formatFloat(v.sum), h.n, labelString(h.labels, lvs), v.count)
+		return err
+	})
+}
+
+func (h *Histogram) summary() (l []Sample) {
+	_ = h.each(func(lvs []string, v *histValue) error {
+		avg := 0.0
+		if v.count != 0 {
+			avg = v.sum / float64(v.count)
+		}
+		s := h.sample(lvs, avg)
+		s.Count = v.count
+		l = append(l, s)
+		return nil
+	})
+	return
+}
+
+// endregion
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("kokpit_rows_total", "Rows written.", "scope")
+	c.Inc("sandiklarMV")
+	c.Add(2, `a"b\c`+"\n\td")
+	g := r.NewGauge("kokpit_running", "Running\nscopes \\ flag.", "scope")
+	g.Set(1, "İZMİR")
+	r.NewGaugeFunc("kokpit_up", "Always one.", func() float64 { return 1 })
+	h := r.NewHistogram("kokpit_seconds", "Latency.", []float64{1, 0.5}, "endpoint")
+	for _, x := range []float64{0.2, 0.7, 3} {
+		h.Observe(x, "getIlList")
+	}
+
+	var buf bytes.Buffer
+	if err := r.WritePrometheus(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		`# HELP kokpit_rows_total Rows written.`,
+		`# TYPE kokpit_rows_total counter`,
+		`kokpit_rows_total{scope="a\"b\\c\n` + "\t" + `d"} 2`,
+		`kokpit_rows_total{scope="sandiklarMV"} 1`,
+		`# HELP kokpit_running Running\nscopes \\ flag.`,
+		`# TYPE kokpit_running gauge`,
+		`kokpit_running{scope="İZMİR"} 1`,
+		`# HELP kokpit_up Always one.`,
+		`# TYPE kokpit_up gauge`,
+		`kokpit_up 1`,
+		`# HELP kokpit_seconds Latency.`,
+		`# TYPE kokpit_seconds histogram`,
+		`kokpit_seconds_bucket{endpoint="getIlList",le="0.5"} 1`,
+		`kokpit_seconds_bucket{endpoint="getIlList",le="1"} 2`,
+		`kokpit_seconds_bucket{endpoint="getIlList",le="+Inf"} 3`,
+		`kokpit_seconds_sum{endpoint="getIlList"} 3.9`,
+		`kokpit_seconds_count{endpoint="getIlList"} 3`,
+	}, "\n") + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRegistryRejectsDuplicates(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("x_total", "x")
+	defer func() {
+		if recover() == nil {
+			t.Error("duplicate metric registered")
+		}
+	}()
+	r.NewGauge("x_total", "x")
+}
+
+func TestSummary(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("rows_total", "Rows.", "scope", "tur")
+	c.Add(2, "b", "MV")
+	c.Inc("a", "CB")
+	r.NewGaugeFunc("up", "Up.", func() float64 { return 1 })
+	h := r.NewHistogram("seconds", "Latency.", []float64{1}, "endpoint")
+	h.Observe(1, "getIlList")
+	h.Observe(2, "getIlList")
+	want := []Sample{
+		{Name: "rows_total", Type: "counter", Labels: []Label{{"scope", "a"}, {"tur", "CB"}}, Value: 1},
+		{Name: "rows_total", Type: "counter", Labels: []Label{{"scope", "b"}, {"tur", "MV"}}, Value: 2},
+		{Name: "up", Type: "gauge", Value: 1},
+		{Name: "seconds", Type: "histogram", Labels: []Label{{"endpoint", "getIlList"}}, Value: 1.5, Count: 2},
+	}
+	if got := r.Summary(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
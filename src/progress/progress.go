@@ -0,0 +1,233 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// region Task
+
+// Task tracks the units (provinces, countries, customs gates, ...) one
+// goroutine has to go through. All methods are safe on a nil Task so callers
+// without a board can pass nil.
+type Task struct {
+	name     string
+	total    int64
+	done     int64
+	start    time.Time
+	finished int64 // unix nanos, 0 while running
+}
+
+// AddTotal adds n units to the work left. Work done in several passes over
+// the same list should be added up front; a total raised between passes
+// shows 100% with ETA 0s after the first one.
+func (t *Task) AddTotal(n int) {
+	if t != nil {
+		atomic.AddInt64(&t.total, int64(n))
+	}
+}
+
+// Inc marks one unit as done.
+func (t *Task) Inc() {
+	if t != nil {
+		atomic.AddInt64(&t.done, 1)
+	}
+}
+
+// Counts returns the units done and the total so far.
+func (t *Task) Counts() (done, total int) {
+	if t == nil {
+		return 0, 0
+	}
+	return int(atomic.LoadInt64(&t.done)), int(atomic.LoadInt64(&t.total))
+}
+
+// Finish marks the task as complete.
+func (t *Task) Finish() {
+	if t != nil {
+		atomic.CompareAndSwapInt64(&t.finished, 0, time.Now().UnixNano())
+	}
+}
+
+// ETA estimates the time left from the average rate so far; ok is false
+// while there is not enough data.
+func (t *Task) ETA(now time.Time) (eta time.Duration, ok bool) {
+	done, total := atomic.LoadInt64(&t.done), atomic.LoadInt64(&t.total)
+	if done == 0 || total <= done {
+		return 0, total != 0 && total <= done
+	}
+	perUnit := now.Sub(t.start) / time.Duration(done)
+	return perUnit * time.Duration(total-done), true
+}
+
+func (t *Task) line(now time.Time, width int) string {
+	done, total := atomic.LoadInt64(&t.done), atomic.LoadInt64(&t.total)
+	pct := 0.0
+	if total != 0 {
+		pct = 100 * float64(done) / float64(total)
+	}
+	s := fmt.Sprintf("%-*s %5d/%-5d %5.1f%%", width, t.name, done, total, pct)
+	if f := atomic.LoadInt64(&t.finished); f != 0 {
+		return s + "  bitti " + time.Unix(0, f).Sub(t.start).Round(time.Second).String()
+	}
+	if eta, ok := t.ETA(now); ok {
+		return s + "  ETA " + eta.Round(time.Second).String()
+	}
+	return s + "  ETA ?"
+}
+
+// endregion
+// region Board
+
+// Board renders one line per task. On a terminal the lines are redrawn in
+// place; otherwise they are written as plain lines on every tick.
+type Board struct {
+	mu     sync.Mutex
+	w      io.Writer
+	tty    bool
+	tasks  []*Task
+	footer func() string
+	drawn  int
+}
+
+func New(w io.Writer, tty bool) *Board {
+	return &Board{w: w, tty: tty}
+}
+
+// IsTerminal reports whether f is a character device such as a terminal.
+func IsTerminal(f *os.File) bool {
+	st, err := f.Stat()
+	return err == nil && st.Mode()&os.ModeCharDevice != 0
+}
+
+// TTY reports whether the board redraws in place.
+func (b *Board) TTY() bool {
+	return b.tty
+}
+
+// Task adds a task to the board.
+func (b *Board) Task(name string) *Task {
+	t := &Task{name: name, start: time.Now()}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tasks = append(b.tasks, t)
+	return t
+}
+
+// Footer sets a function whose result is rendered below the tasks, for
+// process wide numbers like request rate and memory.
+func (b *Board) Footer(fn func() string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.footer = fn
+}
+
+// Run renders the board every interval until the returned stop function is
+// called; stop renders a final frame.
+func (b *Board) Run(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		tick := time.NewTicker(interval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-tick.C:
+				b.Render()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-exited
+		b.Render()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		// keep the last frame on screen
+		b.drawn = 0
+	}
+}
+
+// Render draws the current state of all tasks.
+func (b *Board) Render() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clear()
+	b.draw()
+}
+
+func (b *Board) lines() []string {
+	now := time.Now()
+	width := 0
+	for _, t := range b.tasks {
+		if len(t.name) > width {
+			width = len(t.name)
+		}
+	}
+	l := make([]string, 0, len(b.tasks)+1)
+	for _, t := range b.tasks {
+		l = append(l, t.line(now, width))
+	}
+	if b.footer != nil {
+		l = append(l, b.footer())
+	}
+	return l
+}
+
+// clear erases the previous frame on a terminal; caller holds mu.
+func (b *Board) clear() {
+	if b.tty && b.drawn > 0 {
+		_, _ = fmt.Fprintf(b.w, "\x1b[%dA\x1b[J", b.drawn)
+	}
+	b.drawn = 0
+}
+
+// draw writes a frame; caller holds mu.
+func (b *Board) draw() {
+	l := b.lines()
+	if !b.tty {
+		for i := range l {
+			l[i] = "ilerleme: " + l[i]
+		}
+	}
+	_, _ = io.WriteString(b.w, strings.Join(l, "\n")+"\n")
+	if b.tty {
+		b.drawn = len(l)
+	}
+}
+
+// Writer wraps w, usually the log output, so that lines written to it appear
+// above the board instead of tearing the frame apart.
+func (b *Board) Writer(w io.Writer) io.Writer {
+	if !b.tty {
+		return w
+	}
+	return boardWriter{b: b, w: w}
+}
+
+type boardWriter struct {
+	b *Board
+	w io.Writer
+}
+
+func (bw boardWriter) Write(p []byte) (int, error) {
+	bw.b.mu.Lock()
+	defer bw.b.mu.Unlock()
+	redraw := bw.b.drawn > 0
+	bw.b.clear()
+	n, err := bw.w.Write(p)
+	if redraw {
+		bw.b.draw()
+	}
+	return n, err
+}
+
+// endregion
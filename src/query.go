@@ -0,0 +1,362 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"github.com/secim/src/client"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// region Query
+
+// Query istek parametreleri; Encode query string'i sabit sirayla doner
+type Query interface {
+	Encode() string
+}
+
+// Params serbest parametreler; Encode anahtarlari alfabetik siralar
+type Params map[string]any
+
+func (p Params) Encode() string {
+	vals := url.Values{}
+	for k, v := range p {
+		vals[k] = []string{fmt.Sprintf("%v", v)}
+	}
+	return vals.Encode()
+}
+
+// endregion
+// region SandikSonucQuery
+
+// sandikTuru degerleri
+const (
+	SandikTuruIlce           = 0
+	SandikTuruGumruk         = 1
+	SandikTuruCezaevi        = 2
+	SandikTuruDisTemsilcilik = 3
+)
+
+// yurtIciDisi degerleri
+const (
+	YurtIci  = 1
+	YurtDisi = 2
+)
+
+// SandikSonucQuery getSecimSandikSonucList parametreleri. Sifir id'ler api
+// gibi bos gonderilir; sadece yurt ici sandiklarda il, ilce, belde, birim ve
+// secim cevresi id'leri 0 olsa da yazilir (ornek: beldeId=0).
+type SandikSonucQuery struct {
+	SecimID          int
+	SecimTuru        int
+	SandikTuru       int
+	YurtIciDisi      int
+	IlID             int
+	IlceID           int
+	BeldeID          int
+	BirimID          int
+	MuhtarlikID      int
+	CezaeviID        int
+	SandikNoIlk      int
+	SandikNoSon      int
+	UlkeID           int
+	DisTemsilcilikID int
+	GumrukID         int
+	SandikRumuzIlk   string
+	SandikRumuzSon   string
+	SecimCevresiID   int
+	SandikID         int
+}
+
+// Validate sandikTuru / yurtIciDisi uyumunu ve turun gerektirdigi id'leri kontrol eder
+func (q SandikSonucQuery) Validate() error {
+	if q.SecimID == 0 {
+		return fmt.Errorf("secimId is required")
+	}
+	if q.SecimTuru != 8 && q.SecimTuru != 9 {
+		return fmt.Errorf("invalid secimTuru: %d", q.SecimTuru)
+	}
+	var yid int
+	var gerekli []string
+	switch q.SandikTuru {
+	case SandikTuruIlce, SandikTuruCezaevi:
+		yid = YurtIci
+		if q.IlID == 0 {
+			gerekli = append(gerekli, "ilId")
+		}
+		if q.IlceID == 0 {
+			gerekli = append(gerekli, "ilceId")
+		}
+		if q.SecimCevresiID == 0 {
+			gerekli = append(gerekli, "secimCevresiId")
+		}
+	case SandikTuruGumruk:
+		yid = YurtDisi
+		if q.GumrukID == 0 {
+			gerekli = append(gerekli, "gumrukId")
+		}
+	case SandikTuruDisTemsilcilik:
+		yid = YurtDisi
+		if q.UlkeID == 0 {
+			gerekli = append(gerekli, "ulkeId")
+		}
+		if q.DisTemsilcilikID == 0 {
+			gerekli = append(gerekli, "disTemsilcilikId")
+		}
+	default:
+		return fmt.Errorf("invalid sandikTuru: %d", q.SandikTuru)
+	}
+	if q.YurtIciDisi != yid {
+		return fmt.Errorf("sandikTuru %d needs yurtIciDisi %d, got %d", q.SandikTuru, yid, q.YurtIciDisi)
+	}
+	if len(gerekli) != 0 {
+		return fmt.Errorf("sandikTuru %d needs %s", q.SandikTuru, strings.Join(gerekli, ", "))
+	}
+	switch {
+	case q.SandikNoIlk < 0 || q.SandikNoSon < 0 || q.SandikID < 0:
+		return fmt.Errorf("negative sandik no or id")
+	case q.SandikNoSon != 0 && q.SandikNoIlk == 0:
+		return fmt.Errorf("sandikNoSon needs sandikNoIlk")
+	case q.SandikNoSon != 0 && q.SandikNoSon < q.SandikNoIlk:
+		return fmt.Errorf("sandikNoSon %d is before sandikNoIlk %d", q.SandikNoSon, q.SandikNoIlk)
+	case q.SandikRumuzSon != "" && q.SandikRumuzIlk == "":
+		return fmt.Errorf("sandikRumuzSon needs sandikRumuzIlk")
+	case q.SandikRumuzIlk != "" && yid != YurtDisi:
+		// rumuzlar sadece yurt disi sandiklarda var
+		return fmt.Errorf("sandikRumuz is only valid abroad")
+	case q.SandikRumuzSon != "" && RumuzKarsilastir(q.SandikRumuzSon, q.SandikRumuzIlk) < 0:
+		return fmt.Errorf("sandikRumuzSon %q is before sandikRumuzIlk %q", q.SandikRumuzSon, q.SandikRumuzIlk)
+	}
+	return nil
+}
+
+// Aralik sorguyu ilk..son sandik nolarina daraltir; son 0 ise sadece ilk sandik
+func (q SandikSonucQuery) Aralik(ilk, son int) SandikSonucQuery {
+	if son == 0 {
+		son = ilk
+	}
+	q.SandikNoIlk, q.SandikNoSon = ilk, son
+	return q
+}
+
+// RumuzAralik yurt disi sorgusunu ilk..son rumuzlarina daraltir; son bos ise sadece ilk
+func (q SandikSonucQuery) RumuzAralik(ilk, son string) SandikSonucQuery {
+	if son == "" {
+		son = ilk
+	}
+	q.SandikRumuzIlk, q.SandikRumuzSon = ilk, son
+	return q
+}
+
+// Sandik sorguyu tek bir sandik id'sine daraltir
+func (q SandikSonucQuery) Sandik(id int) SandikSonucQuery {
+	q.SandikID = id
+	return q
+}
+
+// Encode parametreleri kokpit'in kendi istekleriyle ayni sirada yazar
+func (q SandikSonucQuery) Encode() string {
+	yurtIci := q.YurtIciDisi == YurtIci
+	id := func(v int, zorunlu bool) string {
+		if v == 0 && !zorunlu {
+			return ""
+		}
+		return strconv.Itoa(v)
+	}
+	var b strings.Builder
+	for i, p := range [...]struct{ k, v string }{
+		{"secimId", id(q.SecimID, true)},
+		{"secimTuru", id(q.SecimTuru, true)},
+		{"sandikTuru", id(q.SandikTuru, true)},
+		{"yurtIciDisi", id(q.YurtIciDisi, true)},
+		{"ilId", id(q.IlID, yurtIci)},
+		{"ilceId", id(q.IlceID, yurtIci)},
+		{"beldeId", id(q.BeldeID, yurtIci)},
+		{"birimId", id(q.BirimID, yurtIci)},
+		{"muhtarlikId", id(q.MuhtarlikID, false)},
+		{"cezaeviId", id(q.CezaeviID, false)},
+		{"sandikNoIlk", id(q.SandikNoIlk, false)},
+		{"sandikNoSon", id(q.SandikNoSon, false)},
+		{"ulkeId", id(q.UlkeID, false)},
+		{"disTemsilcilikId", id(q.DisTemsilcilikID, false)},
+		{"gumrukId", id(q.GumrukID, false)},
+		{"sandikRumuzIlk", q.SandikRumuzIlk},
+		{"sandikRumuzSon", q.SandikRumuzSon},
+		{"secimCevresiId", id(q.SecimCevresiID, yurtIci)},
+		{"sandikId", id(q.SandikID, false)},
+	} {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(p.k)
+		b.WriteByte('=')
+		b.WriteString(url.QueryEscape(p.v))
+	}
+	return b.String()
+}
+
+func (q SandikSonucQuery) String() string {
+	return q.Encode()
+}
+
+// Icerir satirin sorgunun sandik no / rumuz / id kosullarina uydugunu doner.
+// Api bu parametreleri yok sayarsa fazla satirlar bununla ayiklanir.
+func (q SandikSonucQuery) Icerir(row map[string]any) bool {
+	if q.SandikID != 0 {
+		if id, ok := satirSayi(row[KeySandikID]); !ok || id != q.SandikID {
+			return false
+		}
+	}
+	if q.SandikNoIlk != 0 {
+		no, ok := satirSayi(row[KeySandikNO])
+		if !ok || no < q.SandikNoIlk || (q.SandikNoSon != 0 && no > q.SandikNoSon) {
+			return false
+		}
+	}
+	// api rumuz araligini her zaman uygulamiyor; satirlar burada da ayiklanir
+	if q.SandikRumuzIlk != "" {
+		r, _ := row[KeySandikRUMUZ].(string)
+		if !(RumuzAraligi{Ilk: q.SandikRumuzIlk, Son: q.SandikRumuzSon}).Icerir(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// satirSayi json'dan gelen sayi veya sayi iceren string degeri int'e cevirir
+func satirSayi(v any) (int, bool) {
+	switch x := v.(type) {
+	case float64:
+		return int(x), true
+	case int:
+		return x, true
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(x))
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// endregion
+// region SandikAraligi
+
+// SandikAraligi bir birimin (muhtarlik, gumruk kapisi, dis temsilcilik)
+// sandik no araligi; api Min/MaxSANDIKNO'yu string olarak doner
+type SandikAraligi struct {
+	Ilk, Son int
+}
+
+func NewSandikAraligi(min, max string) (SandikAraligi, error) {
+	ilk, err := strconv.Atoi(strings.TrimSpace(min))
+	if err != nil {
+		return SandikAraligi{}, fmt.Errorf("min sandik no: %w", err)
+	}
+	son, err := strconv.Atoi(strings.TrimSpace(max))
+	if err != nil {
+		return SandikAraligi{}, fmt.Errorf("max sandik no: %w", err)
+	}
+	return SandikAraligi{Ilk: ilk, Son: son}, nil
+}
+
+func (a SandikAraligi) Icerir(no int) bool {
+	return no >= a.Ilk && no <= a.Son
+}
+
+func (a SandikAraligi) String() string {
+	return fmt.Sprintf("%d-%d", a.Ilk, a.Son)
+}
+
+// RumuzAraligi yurt disi birimlerinin (gumruk kapisi, dis temsilcilik)
+// sandik rumuzu araligi; Son bos ise sadece Ilk
+type RumuzAraligi struct {
+	Ilk, Son string
+}
+
+// rumuzSirasi rumuzu bastaki sayi ve kalan harfler olarak ayirir; bosluklar
+// ve buyuk / kucuk harf farki yok sayilir. Sayisi olmayan rumuz -1 alir.
+func rumuzSirasi(r string) (int, string) {
+	r = strings.ToUpper(strings.Join(strings.Fields(r), ""))
+	i := 0
+	for i < len(r) && r[i] >= '0' && r[i] <= '9' {
+		i++
+	}
+	n, err := strconv.Atoi(r[:i])
+	if err != nil {
+		n = -1
+	}
+	return n, r[i:]
+}
+
+// RumuzKarsilastir iki rumuzu once sayisina sonra harflerine gore karsilastirir:
+// "999 B" < "1001A" < "1001 B"
+func RumuzKarsilastir(a, b string) int {
+	na, sa := rumuzSirasi(a)
+	nb, sb := rumuzSirasi(b)
+	switch {
+	case na != nb:
+		if na < nb {
+			return -1
+		}
+		return 1
+	case sa < sb:
+		return -1
+	case sa > sb:
+		return 1
+	}
+	return 0
+}
+
+func (a RumuzAraligi) Icerir(r string) bool {
+	son := a.Son
+	if son == "" {
+		son = a.Ilk
+	}
+	return RumuzKarsilastir(r, a.Ilk) >= 0 && RumuzKarsilastir(r, son) <= 0
+}
+
+func (a RumuzAraligi) String() string {
+	return a.Ilk + "-" + a.Son
+}
+
+func NewRumuzAraligi(min, max string) (RumuzAraligi, error) {
+	min, max = strings.TrimSpace(min), strings.TrimSpace(max)
+	if min == "" || max == "" {
+		return RumuzAraligi{}, fmt.Errorf("no sandik rumuz range")
+	}
+	return RumuzAraligi{Ilk: min, Son: max}, nil
+}
+
+func (g Gumruk) RumuzAraligi() (RumuzAraligi, error) {
+	return NewRumuzAraligi(g.MinSANDIKRUMUZ, g.MaxSANDIKRUMUZ)
+}
+
+func (d DisTemsilcilik) RumuzAraligi() (RumuzAraligi, error) {
+	return NewRumuzAraligi(d.MinSANDIKRUMUZ, d.MaxSANDIKRUMUZ)
+}
+
+func (m Muh) SandikAraligi() (SandikAraligi, error) {
+	return NewSandikAraligi(m.MinSANDIKNO, m.MaxSANDIKNO)
+}
+
+func (g Gumruk) SandikAraligi() (SandikAraligi, error) {
+	return NewSandikAraligi(g.MinSANDIKNO, g.MaxSANDIKNO)
+}
+
+func (d DisTemsilcilik) SandikAraligi() (SandikAraligi, error) {
+	return NewSandikAraligi(d.MinSANDIKNO, d.MaxSANDIKNO)
+}
+
+// SandikAraligiSonuclari sorgunun sandik no / rumuz / id kosullarina uyan
+// satirlari ceker; butun ilceyi gezmeden tek bir tutanagi kontrol etmek icin
+func SandikAraligiSonuclari(ctx context.Context, c client.Client, q SandikSonucQuery) []map[string]any {
+	var l []map[string]any
+	for _, row := range SecimSandikSonucListesi(ctx, c, q) {
+		if q.Icerir(row) {
+			l = append(l, row)
+		}
+	}
+	return l
+}
+
+// endregion
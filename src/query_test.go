@@ -0,0 +1,199 @@
+package src
+
+import (
+	"strings"
+	"testing"
+)
+
+// ornekURL api.go'daki ornek istek yorumlarini tek satira cevirir
+func ornekURL(params ...string) string {
+	return "https://sspskokpit.ysk.gov.tr/api/ssps/getSecimSandikSonucList?" + strings.Join(params, "&")
+}
+
+func TestSandikSonucQueryDocumentedURLs(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		q    SandikSonucQuery
+		want string
+	}{
+		{
+			name: "ilce",
+			q:    IlceSonucParams(Ilce{IlID: 6, IlceID: 815, BeldeID: 0, BirimID: 3744, SecimCEVRESIID: 404520}, 8),
+			want: ornekURL("secimId=60792", "secimTuru=8", "sandikTuru=0", "yurtIciDisi=1", "ilId=6",
+				"ilceId=815", "beldeId=0", "birimId=3744", "muhtarlikId=", "cezaeviId=", "sandikNoIlk=",
+				"sandikNoSon=", "ulkeId=", "disTemsilcilikId=", "gumrukId=", "sandikRumuzIlk=",
+				"sandikRumuzSon=", "secimCevresiId=404520", "sandikId="),
+		},
+		{
+			name: "cezaevi",
+			q:    CezaeviSonucParams(Ilce{IlID: 1, IlceID: 473, SecimCEVRESIID: 404480}, 8),
+			want: ornekURL("secimId=60792", "secimTuru=8", "sandikTuru=2", "yurtIciDisi=1", "ilId=1",
+				"ilceId=473", "beldeId=0", "birimId=0", "muhtarlikId=", "cezaeviId=", "sandikNoIlk=",
+				"sandikNoSon=", "ulkeId=", "disTemsilcilikId=", "gumrukId=", "sandikRumuzIlk=",
+				"sandikRumuzSon=", "secimCevresiId=404480", "sandikId="),
+		},
+		{
+			name: "gumruk",
+			q:    GumrukSonucParams(Gumruk{GumrukID: 7, IlceID: 901}, 9),
+			want: ornekURL("secimId=60792", "secimTuru=9", "sandikTuru=1", "yurtIciDisi=2", "ilId=",
+				"ilceId=901", "beldeId=", "birimId=", "muhtarlikId=", "cezaeviId=", "sandikNoIlk=",
+				"sandikNoSon=", "ulkeId=", "disTemsilcilikId=", "gumrukId=7", "sandikRumuzIlk=",
+				"sandikRumuzSon=", "secimCevresiId=", "sandikId="),
+		},
+		{
+			name: "disTems",
+			q:    DisTemsSonucParams(DisTemsilcilik{UlkeID: 9893, DisTEMSILCILIKID: 14}, 9),
+			want: ornekURL("secimId=60792", "secimTuru=9", "sandikTuru=3", "yurtIciDisi=2", "ilId=",
+				"ilceId=", "beldeId=", "birimId=", "muhtarlikId=", "cezaeviId=", "sandikNoIlk=",
+				"sandikNoSon=", "ulkeId=9893", "disTemsilcilikId=14", "gumrukId=", "sandikRumuzIlk=",
+				"sandikRumuzSon=", "secimCevresiId=", "sandikId="),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.q.Validate(); err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if got := ornekURL(tc.q.Encode()); got != tc.want {
+				t.Errorf("got  %s\nwant %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSandikSonucQueryValidate(t *testing.T) {
+	ilce := IlceSonucParams(Ilce{IlID: 6, IlceID: 815, SecimCEVRESIID: 404520}, 8)
+	for _, tc := range []struct {
+		name string
+		edit func(q *SandikSonucQuery)
+		want string
+	}{
+		{"secimTuru", func(q *SandikSonucQuery) { q.SecimTuru = 0 }, "invalid secimTuru"},
+		{"sandikTuru", func(q *SandikSonucQuery) { q.SandikTuru = 7 }, "invalid sandikTuru"},
+		{"yurtIciDisi", func(q *SandikSonucQuery) { q.YurtIciDisi = YurtDisi }, "needs yurtIciDisi 1"},
+		{"ilce", func(q *SandikSonucQuery) { q.IlceID, q.SecimCevresiID = 0, 0 }, "needs ilceId, secimCevresiId"},
+		{"gumruk", func(q *SandikSonucQuery) {
+			q.SandikTuru, q.YurtIciDisi = SandikTuruGumruk, YurtDisi
+		}, "needs gumrukId"},
+		{"disTems", func(q *SandikSonucQuery) {
+			q.SandikTuru, q.YurtIciDisi, q.UlkeID = SandikTuruDisTemsilcilik, YurtDisi, 1
+		}, "needs disTemsilcilikId"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			q := ilce
+			tc.edit(&q)
+			err := q.Validate()
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("got %v, want error containing %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestParamsEncodeSorted(t *testing.T) {
+	p := Params{"secimTuru": 8, "secimId": SecimID, "ilId": ""}
+	if got, want := p.Encode(), "ilId=&secimId=60792&secimTuru=8"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestSandikSonucQueryAralik(t *testing.T) {
+	q := GumrukSonucParams(Gumruk{GumrukID: 7, IlceID: 901}, 9).Aralik(3, 0).Sandik(42)
+	if err := q.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	enc := q.Encode()
+	for _, want := range []string{"sandikNoIlk=3", "sandikNoSon=3", "sandikId=42"} {
+		if !strings.Contains(enc, want) {
+			t.Errorf("%s does not contain %s", enc, want)
+		}
+	}
+	for _, tc := range []struct {
+		row  map[string]any
+		want bool
+	}{
+		{map[string]any{KeySandikID: 42.0, KeySandikNO: 3.0}, true},
+		{map[string]any{KeySandikID: 42.0, KeySandikNO: "3"}, true},
+		{map[string]any{KeySandikID: 42.0, KeySandikNO: 4.0}, false},
+		{map[string]any{KeySandikID: 41.0, KeySandikNO: 3.0}, false},
+		{map[string]any{KeySandikNO: 3.0}, false},
+	} {
+		if got := q.Icerir(tc.row); got != tc.want {
+			t.Errorf("Icerir(%v) = %v, want %v", tc.row, got, tc.want)
+		}
+	}
+
+	ilce := IlceSonucParams(Ilce{IlID: 6, IlceID: 815, SecimCEVRESIID: 404520}, 8)
+	if err := ilce.Aralik(5, 2).Validate(); err == nil {
+		t.Error("reversed range passed validation")
+	}
+	if err := ilce.RumuzAralik("1001A", "").Validate(); err == nil {
+		t.Error("rumuz passed validation for a domestic query")
+	}
+	dt := DisTemsSonucParams(DisTemsilcilik{UlkeID: 9893, DisTEMSILCILIKID: 14}, 9).RumuzAralik("1001A", "")
+	if err := dt.Validate(); err != nil {
+		t.Error(err)
+	}
+	if !dt.Icerir(map[string]any{KeySandikRUMUZ: "1001A"}) || dt.Icerir(map[string]any{KeySandikRUMUZ: "1002A"}) {
+		t.Error("single rumuz not filtered")
+	}
+}
+
+func TestSandikAraligi(t *testing.T) {
+	a, err := Gumruk{MinSANDIKNO: "1", MaxSANDIKNO: " 12"}.SandikAraligi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.Icerir(1) || !a.Icerir(12) || a.Icerir(13) {
+		t.Errorf("unexpected range %v", a)
+	}
+	if _, err = (Muh{}).SandikAraligi(); err == nil {
+		t.Error("empty range parsed")
+	}
+}
+
+func TestRumuzAraligi(t *testing.T) {
+	sirali := []string{"", "A", "999 B", "1001A", "1001 b", "1002", "1002A"}
+	for i := range sirali {
+		for j := range sirali {
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if got := RumuzKarsilastir(sirali[i], sirali[j]); got != want {
+				t.Errorf("RumuzKarsilastir(%q, %q) = %d, want %d", sirali[i], sirali[j], got, want)
+			}
+		}
+	}
+	if RumuzKarsilastir("1001 A", "1001a") != 0 {
+		t.Error("spacing and case not ignored")
+	}
+
+	a, err := DisTemsilcilik{MinSANDIKRUMUZ: " 1001 A", MaxSANDIKRUMUZ: "1003A "}.RumuzAraligi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for r, want := range map[string]bool{"1001A": true, "1002 Z": true, "1003 A": true, "1003B": false, "999A": false} {
+		if a.Icerir(r) != want {
+			t.Errorf("%v.Icerir(%q) = %v", a, r, !want)
+		}
+	}
+	if _, err = (Gumruk{MinSANDIKRUMUZ: "1001A"}).RumuzAraligi(); err == nil {
+		t.Error("half range parsed")
+	}
+
+	// rumuz araliklari satirlarda da ayiklanir
+	dt := DisTemsSonucParams(DisTemsilcilik{UlkeID: 9893, DisTEMSILCILIKID: 14}, 9).RumuzAralik("1001A", "1002A")
+	if err := dt.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for r, want := range map[string]bool{"1001 A": true, "1002A": true, "1002B": false, "": false} {
+		if got := dt.Icerir(map[string]any{KeySandikRUMUZ: r}); got != want {
+			t.Errorf("Icerir(%q) = %v, want %v", r, got, want)
+		}
+	}
+	if err := dt.RumuzAralik("1002A", "1001A").Validate(); err == nil {
+		t.Error("reversed rumuz range passed validation")
+	}
+}
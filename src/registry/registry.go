@@ -0,0 +1,295 @@
+package registry
+
+import (
+	"crypto/sha1"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/secim/src"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// YurtdisiCevreID yurt disi basliklarinin kaydedildigi sahte cevre id'si
+const YurtdisiCevreID = 0
+
+// Tur bir basligin ittifak, parti veya bagimsiz aday olmasi
+type Tur string
+
+const (
+	Ittifak  Tur = "ittifak"
+	Parti    Tur = "parti"
+	Bagimsiz Tur = "bagimsiz"
+	Diger    Tur = "diger"
+)
+
+// TurOf column name'in on ekinden turu cikarir
+func TurOf(colName string) Tur {
+	for _, t := range []Tur{Ittifak, Parti, Bagimsiz} {
+		if strings.HasPrefix(colName, string(t)) {
+			return t
+		}
+	}
+	return Diger
+}
+
+func (t Tur) ord() int {
+	switch t {
+	case Ittifak:
+		return 0
+	case Parti:
+		return 1
+	case Bagimsiz:
+		return 2
+	}
+	return 3
+}
+
+// Entry tum secim cevrelerinde ayni ada sahip basliklarin tek kaydi
+type Entry struct {
+	ID          string   `json:"id"`
+	Tur         Tur      `json:"tur"`
+	Ad          string   `json:"ad"`
+	SiraNO      int      `json:"sira_NO"`
+	ColumnNames []string `json:"column_NAMES"`
+	Cevreler    []int    `json:"cevreler"`
+}
+
+// Conflict kaydi durdurmayan isim catismasi
+type Conflict struct {
+	CevreID  int      `json:"cevre_ID"`
+	Tip      string   `json:"tip"`
+	Anahtar  string   `json:"anahtar"`
+	Degerler []string `json:"degerler"`
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("cevre %d: %s %q -> %s", c.CevreID, c.Tip, c.Anahtar, strings.Join(c.Degerler, ", "))
+}
+
+// Registry secim cevresi basina basliklari toplar ve adaylara / partilere
+// cevrelerden bagimsiz, calistirmalar arasinda degismeyen id'ler verir.
+// Eszamanli kullanima uygundur.
+type Registry struct {
+	mu        sync.Mutex
+	entries   map[string]*Entry
+	cevreler  map[int][]src.SecimSonucBaslik
+	goruldu   map[int]map[src.SecimSonucBaslik]bool
+	cols      map[int]map[string]string
+	ads       map[int]map[string]string
+	conflicts []Conflict
+}
+
+func New() *Registry {
+	return &Registry{
+		entries:  make(map[string]*Entry),
+		cevreler: make(map[int][]src.SecimSonucBaslik),
+		goruldu:  make(map[int]map[src.SecimSonucBaslik]bool),
+		cols:     make(map[int]map[string]string),
+		ads:      make(map[int]map[string]string),
+	}
+}
+
+// normalize ad'lari bosluk ve buyuk / kucuk harf farklarindan arindirir
+func normalize(ad string) string {
+	return strings.Join(strings.Fields(strings.ToUpperSpecial(unicode.TurkishCase, ad)), " ")
+}
+
+// EntryID tur ve normalize edilmis addan kararli bir id uretir (ornek: P-1a2b3c4d)
+func EntryID(t Tur, ad string) string {
+	h := sha1.Sum([]byte(string(t) + "\x00" + normalize(ad)))
+	return strings.ToUpper(string(t)[:1]) + "-" + hex.EncodeToString(h[:4])
+}
+
+// Add bir secim cevresinin basliklarini kaydeder ve bu cagrida bulunan
+// catismalari doner. Ayni cevre icinde cakisan sutun adlari / adlar ve cevreler
+// arasi tur farklari Conflicts'e de yazilir. Ayni cevre icin tekrar eklenen
+// basliklar yok sayilir; ayni liste birden fazla komut / kapsamdan eklenebilir.
+func (r *Registry) Add(cevreID int, basliklar []src.SecimSonucBaslik) []Conflict {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := len(r.conflicts)
+	goruldu := r.goruldu[cevreID]
+	if goruldu == nil {
+		goruldu = make(map[src.SecimSonucBaslik]bool)
+		r.goruldu[cevreID] = goruldu
+		r.cols[cevreID] = make(map[string]string)
+		r.ads[cevreID] = make(map[string]string)
+	}
+	cols, adCols := r.cols[cevreID], r.ads[cevreID]
+	for _, b := range basliklar {
+		if goruldu[b] {
+			continue
+		}
+		goruldu[b] = true
+		r.cevreler[cevreID] = append(r.cevreler[cevreID], b)
+		if b.SiraNO == 0 {
+			// backendde bazi adaylar kasten boyle skip edilmis
+			continue
+		}
+		t := TurOf(b.ColumnNAME)
+		if ad, ok := cols[b.ColumnNAME]; ok && ad != b.Ad {
+			r.conflict(cevreID, "sutun", b.ColumnNAME, ad, b.Ad)
+			continue
+		}
+		cols[b.ColumnNAME] = b.Ad
+		if col, ok := adCols[b.Ad]; ok && col != b.ColumnNAME {
+			r.conflict(cevreID, "ad", b.Ad, col, b.ColumnNAME)
+		} else {
+			adCols[b.Ad] = b.ColumnNAME
+		}
+
+		key := normalize(b.Ad)
+		e, ok := r.entries[key]
+		if !ok {
+			e = &Entry{ID: EntryID(t, b.Ad), Tur: t, Ad: b.Ad, SiraNO: b.SiraNO}
+			r.entries[key] = e
+		} else if e.Tur != t {
+			r.conflict(cevreID, "tur", b.Ad, string(e.Tur), string(t))
+		}
+		e.ColumnNames = addUniq(e.ColumnNames, b.ColumnNAME)
+		e.Cevreler = addUniqInt(e.Cevreler, cevreID)
+	}
+	return append([]Conflict(nil), r.conflicts[n:]...)
+}
+
+func (r *Registry) conflict(cevreID int, tip, anahtar string, degerler ...string) {
+	r.conflicts = append(r.conflicts, Conflict{CevreID: cevreID, Tip: tip, Anahtar: anahtar, Degerler: degerler})
+}
+
+func addUniq(l []string, s string) []string {
+	for _, v := range l {
+		if v == s {
+			return l
+		}
+	}
+	l = append(l, s)
+	sort.Strings(l)
+	return l
+}
+
+func addUniqInt(l []int, i int) []int {
+	for _, v := range l {
+		if v == i {
+			return l
+		}
+	}
+	l = append(l, i)
+	sort.Ints(l)
+	return l
+}
+
+// Lookup bir cevredeki sutun adinin kaydini doner
+func (r *Registry) Lookup(cevreID int, colName string) (Entry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ad, ok := r.cols[cevreID][colName]
+	if !ok {
+		return Entry{}, false
+	}
+	e := r.entries[normalize(ad)]
+	return *e, true
+}
+
+// Basliklar bir cevre icin kaydedilmis ham baslik listesini doner
+func (r *Registry) Basliklar(cevreID int) []src.SecimSonucBaslik {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]src.SecimSonucBaslik(nil), r.cevreler[cevreID]...)
+}
+
+// Sutunlar bir cevrenin sonuclarindaki column name'leri basliklara esler.
+// Catisan sutun adlarinda ilk kaydedilen baslik kullanilir; SiraNO 0 olanlar atlanir.
+func (r *Registry) Sutunlar(cevreID int) map[string]src.SecimSonucBaslik {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := make(map[string]src.SecimSonucBaslik)
+	for _, b := range r.cevreler[cevreID] {
+		if _, ok := m[b.ColumnNAME]; !ok && r.kullanilir(cevreID, b) {
+			m[b.ColumnNAME] = b
+		}
+	}
+	return m
+}
+
+// Adlar verilen cevrelerin basliklarini ad -> baslik olarak birlestirir.
+// Ayni ad birden fazla cevrede veya sutunda gecerse, eski union'daki gibi
+// son kayit kullanilir; SiraNO'su ve dolayisiyla sutun sirasi ondan gelir.
+// Sutun catismasini kaybeden basliklar sonuclarda gelmeyecegi icin atlanir.
+func (r *Registry) Adlar(cevreIDs ...int) map[string]src.SecimSonucBaslik {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := make(map[string]src.SecimSonucBaslik)
+	for _, id := range cevreIDs {
+		for _, b := range r.cevreler[id] {
+			if r.kullanilir(id, b) {
+				m[b.Ad] = b
+			}
+		}
+	}
+	return m
+}
+
+// kullanilir baslik atlanmadiysa ve sutun adini ilk alan baslik ise
+func (r *Registry) kullanilir(cevreID int, b src.SecimSonucBaslik) bool {
+	return b.SiraNO != 0 && r.cols[cevreID][b.ColumnNAME] == b.Ad
+}
+
+// Entries kayitlari ittifak, parti, bagimsiz; sonra sira no ve ada gore dizili doner
+func (r *Registry) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l := make([]Entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		l = append(l, *e)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].Tur != l[j].Tur {
+			return l[i].Tur.ord() < l[j].Tur.ord()
+		}
+		if l[i].SiraNO != l[j].SiraNO {
+			return l[i].SiraNO < l[j].SiraNO
+		}
+		return l[i].Ad < l[j].Ad
+	})
+	return l
+}
+
+// Conflicts bulunan catismalari eklenme sirasiyla doner
+func (r *Registry) Conflicts() []Conflict {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Conflict(nil), r.conflicts...)
+}
+
+// WriteCSV kayitlari referans tablosu olarak yazar
+func (r *Registry) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"ID", "TUR", "AD", "SIRA NO", "SUTUN ADLARI", "CEVRE SAYISI"}); err != nil {
+		return err
+	}
+	for _, e := range r.Entries() {
+		if err := cw.Write([]string{
+			e.ID, string(e.Tur), e.Ad, fmt.Sprint(e.SiraNO),
+			strings.Join(e.ColumnNames, " "), fmt.Sprint(len(e.Cevreler)),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON kayitlari ve catismalari tek bir JSON nesnesi olarak yazar
+func (r *Registry) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Kayitlar   []Entry    `json:"kayitlar"`
+		Catismalar []Conflict `json:"catismalar"`
+	}{r.Entries(), r.Conflicts()})
+}
@@ -0,0 +1,143 @@
+package registry
+
+import (
+	"github.com/secim/src"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestEntryIDKararli(t *testing.T) {
+	id := EntryID(Parti, "A PARTİSİ")
+	if !regexp.MustCompile(`^P-[0-9a-f]{8}$`).MatchString(id) {
+		t.Fatalf("unexpected id format %q", id)
+	}
+	// bosluk ve buyuk / kucuk harf farklari ayni id'yi vermeli
+	for _, ad := range []string{"a partisi", "  A   PARTİSİ ", "A partİsİ"} {
+		if got := EntryID(Parti, ad); got != id {
+			t.Errorf("EntryID(%q) = %s, want %s", ad, got, id)
+		}
+	}
+	if EntryID(Bagimsiz, "A PARTİSİ") == id {
+		t.Error("different types share an id")
+	}
+	if EntryID(Parti, "B PARTİSİ") == id {
+		t.Error("different names share an id")
+	}
+
+	// ayni ad farkli cevrelerde farkli sutunla gelse de tek kayit olmali
+	r := New()
+	r.Add(1, []src.SecimSonucBaslik{{SiraNO: 1, Ad: "A PARTİSİ", ColumnNAME: "parti_1"}})
+	r.Add(2, []src.SecimSonucBaslik{{SiraNO: 3, Ad: "a partisi", ColumnNAME: "parti_3"}})
+	l := r.Entries()
+	if len(l) != 1 {
+		t.Fatalf("got %d entries: %+v", len(l), l)
+	}
+	want := Entry{ID: id, Tur: Parti, Ad: "A PARTİSİ", SiraNO: 1,
+		ColumnNames: []string{"parti_1", "parti_3"}, Cevreler: []int{1, 2}}
+	if !reflect.DeepEqual(l[0], want) {
+		t.Errorf("got %+v, want %+v", l[0], want)
+	}
+	if e, ok := r.Lookup(2, "parti_3"); !ok || e.ID != id {
+		t.Errorf("Lookup = %+v, %v", e, ok)
+	}
+	if _, ok := r.Lookup(1, "parti_3"); ok {
+		t.Error("column found in the wrong cevre")
+	}
+}
+
+func TestCatismalar(t *testing.T) {
+	basliklar := []src.SecimSonucBaslik{
+		{SiraNO: 1, Ad: "A PARTİSİ", ColumnNAME: "parti_1"},
+		// ayni sutun adi baska bir adla
+		{SiraNO: 2, Ad: "B PARTİSİ", ColumnNAME: "parti_1"},
+		// ayni ad baska bir sutunla
+		{SiraNO: 3, Ad: "A PARTİSİ", ColumnNAME: "parti_3"},
+		{SiraNO: 1, Ad: "X", ColumnNAME: "bagimsiz_1"},
+		// SiraNO 0 atlanir, catisma sayilmaz
+		{SiraNO: 0, Ad: "GİZLİ", ColumnNAME: "parti_1"},
+	}
+	r := New()
+	got := r.Add(404520, basliklar)
+	// baska bir cevrede ayni ad ittifak olarak
+	got = append(got, r.Add(404600, []src.SecimSonucBaslik{{SiraNO: 1, Ad: "X", ColumnNAME: "ittifak_1"}})...)
+	want := []Conflict{
+		{CevreID: 404520, Tip: "sutun", Anahtar: "parti_1", Degerler: []string{"A PARTİSİ", "B PARTİSİ"}},
+		{CevreID: 404520, Tip: "ad", Anahtar: "A PARTİSİ", Degerler: []string{"parti_1", "parti_3"}},
+		{CevreID: 404600, Tip: "tur", Anahtar: "X", Degerler: []string{"bagimsiz", "ittifak"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Add conflicts:\n got %v\nwant %v", got, want)
+	}
+	if !reflect.DeepEqual(r.Conflicts(), want) {
+		t.Errorf("Conflicts() = %v", r.Conflicts())
+	}
+
+	// ayni liste tekrar eklenince yeni catisma ve ham baslik eklenmez
+	if l := r.Add(404520, basliklar); len(l) != 0 {
+		t.Errorf("re-adding reported %v", l)
+	}
+	if n := len(r.Basliklar(404520)); n != len(basliklar) {
+		t.Errorf("got %d raw headers, want %d", n, len(basliklar))
+	}
+
+	// sutun haritasinda ilk, ad haritasinda son baslik kullanilir
+	sut := r.Sutunlar(404520)
+	if len(sut) != 3 || sut["parti_1"].Ad != "A PARTİSİ" || sut["parti_3"].SiraNO != 3 {
+		t.Errorf("Sutunlar = %v", sut)
+	}
+	adlar := r.Adlar(404520, 404600)
+	if len(adlar) != 2 || adlar["A PARTİSİ"].ColumnNAME != "parti_3" || adlar["X"].ColumnNAME != "ittifak_1" {
+		t.Errorf("Adlar = %v", adlar)
+	}
+	for _, ad := range []string{"GİZLİ", "B PARTİSİ"} {
+		if _, ok := adlar[ad]; ok {
+			t.Errorf("skipped header %s in Adlar", ad)
+		}
+	}
+}
+
+// eskiBirlesim registry'den onceki union: tum cevrelerin basliklari arka
+// arkaya, SiraNO 0 atlanir, ayni ad icin son baslik kalir
+func eskiBirlesim(cevreler ...[]src.SecimSonucBaslik) map[string]src.SecimSonucBaslik {
+	m := make(map[string]src.SecimSonucBaslik)
+	for _, l := range cevreler {
+		for _, b := range l {
+			if b.SiraNO != 0 {
+				m[b.Ad] = b
+			}
+		}
+	}
+	return m
+}
+
+func TestAdlarEskiBirlesim(t *testing.T) {
+	// ortak adaylarin sira no'su cevreden cevreye degisir; birlesimdeki
+	// SiraNO sutun sirasini belirler
+	ankara := []src.SecimSonucBaslik{
+		{SiraNO: 1, Ad: "A PARTİSİ", ColumnNAME: "parti_1"},
+		{SiraNO: 2, Ad: "B PARTİSİ", ColumnNAME: "parti_2"},
+		{SiraNO: 1, Ad: "X", ColumnNAME: "bagimsiz_1"},
+		{SiraNO: 0, Ad: "GİZLİ", ColumnNAME: "bagimsiz_9"},
+	}
+	izmir := []src.SecimSonucBaslik{
+		{SiraNO: 1, Ad: "B PARTİSİ", ColumnNAME: "parti_1"},
+		{SiraNO: 2, Ad: "A PARTİSİ", ColumnNAME: "parti_2"},
+		{SiraNO: 2, Ad: "Y", ColumnNAME: "bagimsiz_2"},
+	}
+	bursa := []src.SecimSonucBaslik{
+		{SiraNO: 3, Ad: "B PARTİSİ", ColumnNAME: "parti_3"},
+		{SiraNO: 1, Ad: "Y", ColumnNAME: "bagimsiz_1"},
+	}
+	r := New()
+	r.Add(6, ankara)
+	r.Add(35, izmir)
+	r.Add(16, bursa)
+	got := r.Adlar(6, 35, 16)
+	if want := eskiBirlesim(ankara, izmir, bursa); !reflect.DeepEqual(got, want) {
+		t.Errorf("Adlar:\n got %v\nwant %v", got, want)
+	}
+	if got["A PARTİSİ"].SiraNO != 2 || got["B PARTİSİ"].SiraNO != 3 || got["Y"].SiraNO != 1 {
+		t.Errorf("last header did not win: %v", got)
+	}
+}
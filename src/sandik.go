@@ -0,0 +1,175 @@
+package src
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/secim/src/client"
+	"strconv"
+	"strings"
+)
+
+// region SandikSonuc
+
+// getSecimSandikSonucList satirlarindaki sabit alanlarin adlari
+const (
+	KeySandikID         = "sandik_ID"
+	KeySandikNO         = "sandik_NO"
+	KeySandikRUMUZ      = "sandik_RUMUZ"
+	KeyIlID             = "il_ID"
+	KeyIlADI            = "il_ADI"
+	KeyIlceID           = "ilce_ID"
+	KeyIlceADI          = "ilce_ADI"
+	KeyMuhtarlikID      = "muhtarlik_ID"
+	KeyMuhtarlikADI     = "muhtarlik_ADI"
+	KeySecmenSAYISI     = "secmen_SAYISI"
+	KeyOyKULLANAN       = "oy_KULLANAN_SECMEN_SAYISI"
+	KeyGecerliOY        = "gecerli_OY_TOPLAMI"
+	KeyGecersizOY       = "gecersiz_OY_TOPLAMI"
+	KeyItirazliGECERLI  = "itirazli_GECERLI_OY_SAYISI"
+	KeyItirazsizGECERLI = "itirazsiz_GECERLI_OY_SAYISI"
+)
+
+// oy sutunlarinin on ekleri; SecimSonucBaslik.ColumnNAME ile ayni
+var oyOnEkleri = []string{"ittifak", "parti", "bagimsiz"}
+
+// SandikSonuc getSecimSandikSonucList'in tek bir satiri.
+// Adaylara / partilere gore degisen oy sutunlari Votes icinde, column name ile tutulur.
+// Taninmayan diger alanlar Extra'da oldugu gibi saklanir.
+//
+// Toplama yapan kodlar (harita) bu tipi kullanir. Csv yazan kapsamlar (sandik,
+// izle, tutanak) bilerek SecimSandikSonucListesi'nin ham satirlarini yazar:
+// sabit alanlarda null 0'a doner, ham satirda ise bos hucre olarak kalir
+// (sayilmamis sandik ile 0 oy ayni degildir) ve api'nin getirdigi her alan
+// sutun adi ve degeriyle oldugu gibi ciktiya gecer.
+type SandikSonuc struct {
+	SandikID                 int
+	SandikNO                 int
+	SandikRUMUZ              string
+	IlID                     int
+	IlADI                    string
+	IlceID                   int
+	IlceADI                  string
+	MuhtarlikID              int
+	MuhtarlikADI             string
+	SecmenSAYISI             int
+	OyKULLANANSECMENSAYISI   int
+	GecerliOYTOPLAMI         int
+	GecersizOYTOPLAMI        int
+	ItirazliGECERLIOYSAYISI  int
+	ItirazsizGECERLIOYSAYISI int
+	Votes                    map[string]int
+	Extra                    map[string]any
+}
+
+// IsVoteColumn sutun adinin ittifak / parti / bagimsiz oy sutunu olup olmadigini doner
+func IsVoteColumn(colName string) bool {
+	for _, p := range oyOnEkleri {
+		if strings.HasPrefix(colName, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SandikSonuc) UnmarshalJSON(b []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	*s = SandikSonuc{Votes: make(map[string]int)}
+	ints := map[string]*int{
+		KeySandikID: &s.SandikID, KeySandikNO: &s.SandikNO,
+		KeyIlID: &s.IlID, KeyIlceID: &s.IlceID, KeyMuhtarlikID: &s.MuhtarlikID,
+		KeySecmenSAYISI: &s.SecmenSAYISI, KeyOyKULLANAN: &s.OyKULLANANSECMENSAYISI,
+		KeyGecerliOY: &s.GecerliOYTOPLAMI, KeyGecersizOY: &s.GecersizOYTOPLAMI,
+		KeyItirazliGECERLI: &s.ItirazliGECERLIOYSAYISI, KeyItirazsizGECERLI: &s.ItirazsizGECERLIOYSAYISI,
+	}
+	strs := map[string]*string{
+		KeySandikRUMUZ: &s.SandikRUMUZ, KeyIlADI: &s.IlADI,
+		KeyIlceADI: &s.IlceADI, KeyMuhtarlikADI: &s.MuhtarlikADI,
+	}
+	for k, raw := range m {
+		if p, ok := ints[k]; ok {
+			v, _, err := flexInt(raw)
+			if err != nil {
+				return fmt.Errorf("%s: %w", k, err)
+			}
+			*p = v
+		} else if p, ok := strs[k]; ok {
+			v, err := flexString(raw)
+			if err != nil {
+				return fmt.Errorf("%s: %w", k, err)
+			}
+			*p = v
+		} else if IsVoteColumn(k) {
+			v, ok, err := flexInt(raw)
+			if err != nil {
+				return fmt.Errorf("%s: %w", k, err)
+			} else if ok {
+				// null gelen oy sutunu bu sandikta o aday / parti yok demek
+				s.Votes[k] = v
+			}
+		} else {
+			var v any
+			if err := json.Unmarshal(raw, &v); err != nil {
+				return fmt.Errorf("%s: %w", k, err)
+			}
+			if s.Extra == nil {
+				s.Extra = make(map[string]any)
+			}
+			s.Extra[k] = v
+		}
+	}
+	return nil
+}
+
+// flexInt sayi, sayi iceren string veya null kabul eder; null icin ok = false
+func flexInt(raw json.RawMessage) (v int, ok bool, err error) {
+	raw = bytes.TrimSpace(raw)
+	if bytes.Equal(raw, []byte("null")) {
+		return 0, false, nil
+	}
+	if len(raw) != 0 && raw[0] == '"' {
+		var s string
+		if err = json.Unmarshal(raw, &s); err != nil {
+			return
+		}
+		if s = strings.TrimSpace(s); s == "" {
+			return 0, false, nil
+		}
+		raw = json.RawMessage(s)
+	}
+	var f float64
+	if err = json.Unmarshal(raw, &f); err != nil {
+		return 0, false, fmt.Errorf("not a number: %s", raw)
+	}
+	return int(f), true, nil
+}
+
+// flexString string, sayi veya null kabul eder
+func flexString(raw json.RawMessage) (string, error) {
+	raw = bytes.TrimSpace(raw)
+	if bytes.Equal(raw, []byte("null")) {
+		return "", nil
+	}
+	if len(raw) != 0 && raw[0] == '"' {
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	}
+	var f float64
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return "", fmt.Errorf("not a string: %s", raw)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64), nil
+}
+
+// SandikSonuclari SecimSandikSonucListesi'nin tipli hali
+func SandikSonuclari(ctx context.Context, c client.Client, q SandikSonucQuery) []SandikSonuc {
+	mustValidate(q)
+	return MustGet[[]SandikSonuc](ctx, c, "getSecimSandikSonucList", q)
+}
+
+// endregion
@@ -0,0 +1,76 @@
+package src
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSandikSonucUnmarshal(t *testing.T) {
+	const in = `{
+		"sandik_ID": 123456, "sandik_NO": "1001", "sandik_RUMUZ": null,
+		"il_ID": 6, "il_ADI": "ANKARA", "ilce_ID": 815, "ilce_ADI": "CANKAYA",
+		"muhtarlik_ID": 42, "muhtarlik_ADI": "KIZILAY",
+		"secmen_SAYISI": 350, "oy_KULLANAN_SECMEN_SAYISI": 300.0,
+		"gecerli_OY_TOPLAMI": 290, "gecersiz_OY_TOPLAMI": 10,
+		"itirazli_GECERLI_OY_SAYISI": 0, "itirazsiz_GECERLI_OY_SAYISI": 290,
+		"ittifak_1": 200, "parti_1": 150, "parti_2": "50", "parti_3": null,
+		"bagimsiz_1": 40, "tutanak_URL": "x.pdf"
+	}`
+	var s SandikSonuc
+	if err := json.Unmarshal([]byte(in), &s); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	want := SandikSonuc{
+		SandikID: 123456, SandikNO: 1001,
+		IlID: 6, IlADI: "ANKARA", IlceID: 815, IlceADI: "CANKAYA",
+		MuhtarlikID: 42, MuhtarlikADI: "KIZILAY",
+		SecmenSAYISI: 350, OyKULLANANSECMENSAYISI: 300,
+		GecerliOYTOPLAMI: 290, GecersizOYTOPLAMI: 10,
+		ItirazliGECERLIOYSAYISI: 0, ItirazsizGECERLIOYSAYISI: 290,
+		Votes: map[string]int{"ittifak_1": 200, "parti_1": 150, "parti_2": 50, "bagimsiz_1": 40},
+		Extra: map[string]any{"tutanak_URL": "x.pdf"},
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("got %+v\nwant %+v", s, want)
+	}
+}
+
+func TestSandikSonucUnmarshalList(t *testing.T) {
+	var l []SandikSonuc
+	if err := json.Unmarshal([]byte(`[{"sandik_NO": 1, "parti_1": 3}, {"sandik_NO": 2}]`), &l); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(l) != 2 || l[0].SandikNO != 1 || l[1].SandikNO != 2 {
+		t.Fatalf("unexpected rows: %+v", l)
+	}
+	// onceki satirin oylari sonrakine tasinmamali
+	if l[1].Votes == nil || len(l[1].Votes) != 0 {
+		t.Errorf("votes leaked between rows: %+v", l[1].Votes)
+	}
+}
+
+func TestSandikSonucUnmarshalErrors(t *testing.T) {
+	for _, in := range []string{
+		`{"secmen_SAYISI": "abc"}`,
+		`{"parti_1": true}`,
+		`{"il_ADI": {}}`,
+		`[]`,
+	} {
+		var s SandikSonuc
+		if err := json.Unmarshal([]byte(in), &s); err == nil {
+			t.Errorf("expected error for %s, got %+v", in, s)
+		}
+	}
+}
+
+func TestIsVoteColumn(t *testing.T) {
+	for col, want := range map[string]bool{
+		"ittifak_1": true, "parti_12": true, "bagimsiz_3": true,
+		"secmen_SAYISI": false, "sandik_NO": false, "": false,
+	} {
+		if got := IsVoteColumn(col); got != want {
+			t.Errorf("IsVoteColumn(%q) = %v, want %v", col, got, want)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/secim/src/client"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// region Recorder
+
+// Recorder is a Client decorator that decodes every response a second time
+// against the Go type it is unmarshalled into and records, per endpoint,
+// which JSON keys were seen, which of them the type does not know about
+// (they are silently dropped by json.Unmarshal) and which fields of the type
+// never appeared. It must wrap the outermost client to see the typed target.
+type Recorder struct {
+	next client.Client
+
+	mu        sync.Mutex
+	endpoints map[string]*endpoint
+	onDrift   func(Drift)
+	base      Snapshot
+}
+
+type endpoint struct {
+	seen     map[string]bool
+	unknown  map[string]bool
+	expected map[string]bool
+	// keys already passed to onDrift
+	reported map[string]bool
+}
+
+func New(next client.Client) *Recorder {
+	return &Recorder{next: next, endpoints: make(map[string]*endpoint)}
+}
+
+// Compare sets the snapshot to compare against; fn is called for a response
+// that brings keys missing from the snapshot or unknown to the Go type, each
+// key being reported once. Removed keys and missing fields can only be told
+// once all responses are in; they are left to Report.
+func (r *Recorder) Compare(base Snapshot, fn func(Drift)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.base, r.onDrift = base, fn
+}
+
+func (r *Recorder) Request(ctx context.Context, uri string, resp any) error {
+	var raw json.RawMessage
+	if err := r.next.Request(ctx, uri, &raw); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, resp); err != nil {
+		return err
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return err
+	}
+	name := "invalid"
+	if u, err := url.Parse(uri); err == nil {
+		name = client.Endpoint(u)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.endpoints[name]
+	if !ok {
+		e = &endpoint{seen: make(map[string]bool), unknown: make(map[string]bool), expected: make(map[string]bool),
+			reported: make(map[string]bool)}
+		r.endpoints[name] = e
+	}
+	e.walk(v, reflect.TypeOf(resp), "")
+	if r.onDrift != nil && r.base != nil {
+		if d := e.fresh(name, r.base); len(d.Added) != 0 || len(d.Unknown) != 0 {
+			r.onDrift(d)
+		}
+	}
+	return nil
+}
+
+var (
+	jsonValueType   = reflect.TypeOf(json.RawMessage(nil))
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	// vote columns are numbered per district: parti_12 -> parti_#
+	numbered = regexp.MustCompile(`_\d+$`)
+)
+
+// walk records the keys of v under path; t is the Go type v is decoded
+// into, nil when any key is accepted. Types with their own UnmarshalJSON
+// (src.SandikSonuc) do not follow their field names, so they accept any key.
+func (e *endpoint) walk(v any, t reflect.Type, path string) {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t != nil && (t.Kind() == reflect.Interface || t == jsonValueType ||
+		reflect.PointerTo(t).Implements(unmarshalerType)) {
+		t = nil
+	}
+	switch x := v.(type) {
+	case []any:
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+		for _, el := range x {
+			e.walk(el, elem, path+"[]")
+		}
+	case map[string]any:
+		var fields map[string]reflect.Type
+		var elem reflect.Type
+		if t != nil && t.Kind() == reflect.Struct {
+			fields = jsonFields(t)
+			for k := range fields {
+				e.expected[join(path, k)] = true
+			}
+		} else if t != nil && t.Kind() == reflect.Map {
+			elem = t.Elem()
+		}
+		for k, val := range x {
+			if fields == nil {
+				p := join(path, numbered.ReplaceAllString(k, "_#"))
+				e.seen[p] = true
+				e.walk(val, elem, p)
+				continue
+			}
+			name, ft, ok := lookup(fields, k)
+			p := join(path, name)
+			e.seen[p] = true
+			if !ok {
+				e.unknown[p] = true
+				continue
+			}
+			e.walk(val, ft, p)
+		}
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// jsonFields maps the JSON names of t's exported fields to their types,
+// following encoding/json's tag rules (embedded structs are flattened).
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	m := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			for k, v := range jsonFields(f.Type) {
+				m[k] = v
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		m[name] = f.Type
+	}
+	return m
+}
+
+// lookup matches keys case-insensitively, like json.Unmarshal, and returns
+// the field's own name so both spellings count as the same key.
+func lookup(fields map[string]reflect.Type, key string) (string, reflect.Type, bool) {
+	if t, ok := fields[key]; ok {
+		return key, t, true
+	}
+	for k, t := range fields {
+		if strings.EqualFold(k, key) {
+			return k, t, true
+		}
+	}
+	return key, nil, false
+}
+
+// endregion
+// region Snapshot
+
+// Snapshot is the set of keys seen per endpoint, stored as JSON.
+type Snapshot map[string][]string
+
+func LoadSnapshot(fn string) (Snapshot, error) {
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	var s Snapshot
+	if err = json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	return s, nil
+}
+
+func (s Snapshot) Save(fn string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	// same permissions as the output files
+	return os.WriteFile(fn, append(b, '\n'), 0o640)
+}
+
+// Snapshot returns the keys seen so far. Endpoints missing from this run are
+// taken over from the compared snapshot so a partial run does not drop them.
+func (r *Recorder) Snapshot() Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := make(Snapshot, len(r.endpoints))
+	for name, keys := range r.base {
+		s[name] = keys
+	}
+	for name, e := range r.endpoints {
+		s[name] = sortedKeys(e.seen)
+	}
+	return s
+}
+
+func sortedKeys(m map[string]bool) []string {
+	l := make([]string, 0, len(m))
+	for k := range m {
+		l = append(l, k)
+	}
+	sort.Strings(l)
+	return l
+}
+
+// endregion
+// region Drift
+
+// Drift lists the differences found for one endpoint. Added and Removed are
+// relative to the snapshot; Unknown keys are not decoded by the Go type and
+// Missing fields of the type never appeared in a response.
+type Drift struct {
+	Endpoint string   `json:"endpoint"`
+	Added    []string `json:"added,omitempty"`
+	Removed  []string `json:"removed,omitempty"`
+	Unknown  []string `json:"unknown,omitempty"`
+	Missing  []string `json:"missing,omitempty"`
+}
+
+// Empty reports whether the endpoint has no drift from the snapshot.
+// Unknown and missing fields alone are diagnostics, not drift.
+func (d Drift) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+func (d Drift) String() string {
+	var parts []string
+	for _, p := range []struct {
+		name string
+		keys []string
+	}{{"added", d.Added}, {"removed", d.Removed}, {"unknown", d.Unknown}, {"missing", d.Missing}} {
+		if len(p.keys) != 0 {
+			parts = append(parts, p.name+": "+strings.Join(p.keys, ", "))
+		}
+	}
+	return d.Endpoint + ": " + strings.Join(parts, "; ")
+}
+
+func (e *endpoint) drift(name string, base Snapshot) Drift {
+	d := Drift{Endpoint: name, Unknown: sortedKeys(e.unknown)}
+	for _, k := range sortedKeys(e.expected) {
+		if !e.seen[k] {
+			d.Missing = append(d.Missing, k)
+		}
+	}
+	if keys, ok := base[name]; ok {
+		old := make(map[string]bool, len(keys))
+		for _, k := range keys {
+			old[k] = true
+			if !e.seen[k] {
+				d.Removed = append(d.Removed, k)
+			}
+		}
+		for _, k := range sortedKeys(e.seen) {
+			if !old[k] {
+				d.Added = append(d.Added, k)
+			}
+		}
+	}
+	return d
+}
+
+// fresh returns the added and unknown keys not reported before and marks
+// them reported.
+func (e *endpoint) fresh(name string, base Snapshot) Drift {
+	d := Drift{Endpoint: name}
+	for _, k := range sortedKeys(e.unknown) {
+		if !e.reported[k] {
+			d.Unknown = append(d.Unknown, k)
+		}
+	}
+	if keys, ok := base[name]; ok {
+		old := make(map[string]bool, len(keys))
+		for _, k := range keys {
+			old[k] = true
+		}
+		for _, k := range sortedKeys(e.seen) {
+			if !old[k] && !e.reported[k] {
+				d.Added = append(d.Added, k)
+			}
+		}
+	}
+	for _, l := range [][]string{d.Added, d.Unknown} {
+		for _, k := range l {
+			e.reported[k] = true
+		}
+	}
+	return d
+}
+
+// Report returns the per endpoint diagnostics of this run, compared with the
+// snapshot given to Compare, sorted by endpoint. Endpoints without any
+// finding are left out.
+func (r *Recorder) Report() []Drift {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var l []Drift
+	for name, e := range r.endpoints {
+		d := e.drift(name, r.base)
+		if !d.Empty() || len(d.Unknown) != 0 || len(d.Missing) != 0 {
+			l = append(l, d)
+		}
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].Endpoint < l[j].Endpoint })
+	return l
+}
+
+// endregion
@@ -0,0 +1,175 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/secim/src"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// stub answers every request with the body stored for its uri.
+type stub map[string]string
+
+func (s stub) Request(_ context.Context, uri string, resp any) error {
+	return json.Unmarshal([]byte(s[uri]), resp)
+}
+
+type il struct {
+	ID   int    `json:"il_ID"`
+	Ad   string `json:"il_ADI"`
+	Kod  string `json:"plaka_KODU"`
+	Skip string `json:"-"`
+}
+
+const ilURI = "https://sspskokpit.ysk.gov.tr/api/ssps/getIlList?secimId=1"
+
+func TestReportsUnknownAndMissing(t *testing.T) {
+	r := New(stub{ilURI: `[{"il_ID": 6, "IL_adi": "ANKARA", "yeni_ALAN": true}]`})
+	var l []il
+	if err := r.Request(context.Background(), ilURI, &l); err != nil {
+		t.Fatal(err)
+	}
+	if len(l) != 1 || l[0].Ad != "ANKARA" {
+		t.Fatalf("response not decoded: %+v", l)
+	}
+	rep := r.Report()
+	if len(rep) != 1 {
+		t.Fatalf("got %d endpoints, want 1: %v", len(rep), rep)
+	}
+	d := rep[0]
+	if d.Endpoint != "ssps/getIlList" {
+		t.Errorf("endpoint = %q", d.Endpoint)
+	}
+	if want := []string{"[].yeni_ALAN"}; !reflect.DeepEqual(d.Unknown, want) {
+		t.Errorf("unknown = %v, want %v", d.Unknown, want)
+	}
+	if want := []string{"[].plaka_KODU"}; !reflect.DeepEqual(d.Missing, want) {
+		t.Errorf("missing = %v, want %v", d.Missing, want)
+	}
+	if !d.Empty() {
+		t.Errorf("drift without a snapshot: %v", d)
+	}
+}
+
+func TestComparesWithSnapshot(t *testing.T) {
+	const uri = "https://sspskokpit.ysk.gov.tr/api/ssps/getSecimSandikSonucList?ilceId=1"
+	fn := filepath.Join(t.TempDir(), "schema.json")
+
+	old := New(stub{uri: `[{"sandik_NO": 1, "parti_1": 10, "parti_2": 5, "ittifak_1": 15}]`})
+	var rows []map[string]any
+	if err := old.Request(context.Background(), uri, &rows); err != nil {
+		t.Fatal(err)
+	}
+	if err := old.Snapshot().Save(fn); err != nil {
+		t.Fatal(err)
+	}
+	base, err := LoadSnapshot(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"[].ittifak_#", "[].parti_#", "[].sandik_NO"}; !reflect.DeepEqual(base["ssps/getSecimSandikSonucList"], want) {
+		t.Fatalf("snapshot = %v, want %v", base, want)
+	}
+
+	// more parties is not drift, a renamed key is
+	r := New(stub{uri: `[{"sandikNo": 1, "parti_1": 10, "parti_2": 5, "parti_3": 1, "ittifak_1": 16}]`})
+	var drifts []Drift
+	r.Compare(base, func(d Drift) { drifts = append(drifts, d) })
+	for i := 0; i < 2; i++ {
+		if err := r.Request(context.Background(), uri, &rows); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(drifts) != 1 {
+		t.Fatalf("drift callback called %d times, want once", len(drifts))
+	}
+	if want := (Drift{Endpoint: "ssps/getSecimSandikSonucList", Added: []string{"[].sandikNo"}}); !reflect.DeepEqual(drifts[0], want) {
+		t.Errorf("drift = %v, want %v", drifts[0], want)
+	}
+	// removed keys are only known at the end of the run
+	rep := r.Report()
+	if len(rep) != 1 {
+		t.Fatalf("report = %v", rep)
+	}
+	d := rep[0]
+	if want := []string{"[].sandikNo"}; !reflect.DeepEqual(d.Added, want) {
+		t.Errorf("added = %v, want %v", d.Added, want)
+	}
+	if want := []string{"[].sandik_NO"}; !reflect.DeepEqual(d.Removed, want) {
+		t.Errorf("removed = %v, want %v", d.Removed, want)
+	}
+	if len(d.Unknown) != 0 || len(d.Missing) != 0 {
+		t.Errorf("map rows have no unknown or missing fields: %v", d)
+	}
+}
+
+// seq answers the requests of every uri with the given bodies in order.
+type seq []string
+
+func (s *seq) Request(_ context.Context, _ string, resp any) error {
+	body := (*s)[0]
+	*s = (*s)[1:]
+	return json.Unmarshal([]byte(body), resp)
+}
+
+func TestDriftPerResponse(t *testing.T) {
+	base := Snapshot{"ssps/getIlList": {"[].il_ADI", "[].il_ID", "[].plaka_KODU"}}
+	bodies := seq{
+		// plaka_KODU is optional; its absence in one response is not drift
+		`[{"il_ID": 6, "il_ADI": "ANKARA"}]`,
+		`[{"il_ID": 35, "il_ADI": "İZMİR", "plaka_KODU": "35"}]`,
+		`[{"il_ID": 34, "il_ADI": "İSTANBUL", "yeni_ALAN": 1}]`,
+		`[{"il_ID": 34, "il_ADI": "İSTANBUL", "yeni_ALAN": 1}]`,
+	}
+	r := New(&bodies)
+	var drifts []Drift
+	r.Compare(base, func(d Drift) { drifts = append(drifts, d) })
+	for i := 0; i < 4; i++ {
+		var l []il
+		if err := r.Request(context.Background(), ilURI, &l); err != nil {
+			t.Fatal(err)
+		}
+		if i < 2 && len(drifts) != 0 {
+			t.Fatalf("response %d: false drift %v", i, drifts)
+		}
+	}
+	want := []Drift{{Endpoint: "ssps/getIlList", Added: []string{"[].yeni_ALAN"}, Unknown: []string{"[].yeni_ALAN"}}}
+	if !reflect.DeepEqual(drifts, want) {
+		t.Errorf("drifts = %v, want %v", drifts, want)
+	}
+	rep := r.Report()
+	if len(rep) != 1 || len(rep[0].Removed) != 0 || len(rep[0].Missing) != 0 {
+		t.Errorf("report = %v", rep)
+	}
+}
+
+// types with their own UnmarshalJSON are not checked against their fields
+func TestCustomUnmarshaler(t *testing.T) {
+	const uri = "https://sspskokpit.ysk.gov.tr/api/getSecimSandikSonucList?ilceId=1"
+	r := New(stub{uri: `[{"sandik_ID": 5, "sandik_NO": 1, "parti_1": 10, "parti_2": null, "yeni_ALAN": "x"}]`})
+	var l []src.SandikSonuc
+	if err := r.Request(context.Background(), uri, &l); err != nil {
+		t.Fatal(err)
+	}
+	if len(l) != 1 || l[0].SandikID != 5 || l[0].Votes["parti_1"] != 10 {
+		t.Fatalf("response not decoded: %+v", l)
+	}
+	if rep := r.Report(); len(rep) != 0 {
+		t.Errorf("unexpected findings: %v", rep)
+	}
+	want := []string{"[].parti_#", "[].sandik_ID", "[].sandik_NO", "[].yeni_ALAN"}
+	if got := r.Snapshot()["getSecimSandikSonucList"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshot keys = %v, want %v", got, want)
+	}
+
+	fn := filepath.Join(t.TempDir(), "schema.json")
+	if err := r.Snapshot().Save(fn); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(fn); err != nil || fi.Mode().Perm() != 0o640 {
+		t.Errorf("snapshot file mode: %v, %v", fi, err)
+	}
+}
@@ -0,0 +1,156 @@
+package testserver
+
+import (
+	"fmt"
+	"github.com/secim/src"
+)
+
+// region Fixture
+
+// SandikKey keys sandik result lists by src.SandikTuru* and the id the list
+// is requested with: the ilce for domestic and prison boxes, the customs gate
+// and the mission for boxes abroad.
+func SandikKey(sandikTuru, id int) string {
+	return fmt.Sprintf("%d/%d", sandikTuru, id)
+}
+
+// Fixture is the data served by a Server. CB and MV requests get the same
+// answers; only the parameters that select a list are looked at.
+type Fixture struct {
+	// Iller by sandikTuru: every province under src.SandikTuruIlce, the
+	// provinces with prisons under src.SandikTuruCezaevi
+	Iller map[int][]src.Il
+	// Ilceler by secimCevresiId
+	Ilceler map[int][]src.Ilce
+	// Muhtarliklar by ilceId
+	Muhtarliklar      map[int][]src.Muh
+	Ulkeler           []src.Ulke
+	DisTemsilcilikler map[int][]src.DisTemsilcilik
+	Gumrukler         []src.Gumruk
+	// Basliklar by secimCevresiId; 0 holds the abroad header list
+	Basliklar map[int][]src.SecimSonucBaslik
+	// Sandiklar by SandikKey
+	Sandiklar map[string][]map[string]any
+	MV        src.MVSonuc
+	// Cevreler by secimCevresiId for the milletvekili/birim endpoint
+	Cevreler map[int]src.DVOData
+}
+
+// SandikCount is the number of rows served for a sandikTuru.
+func (f *Fixture) SandikCount(sandikTuru int) (n int) {
+	prefix := fmt.Sprintf("%d/", sandikTuru)
+	for k, rows := range f.Sandiklar {
+		if len(k) > len(prefix) && k[:len(prefix)] == prefix {
+			n += len(rows)
+		}
+	}
+	return
+}
+
+// Default returns a small but complete election: two electoral districts
+// with one prison, one country with one mission and one customs gate.
+func Default() *Fixture {
+	ankara := src.Il{IlID: 6, IlADI: "ANKARA", SecimCEVRESIID: 404520, SecilecekADAYSAYISI: 13, Id: "6"}
+	izmir := src.Il{IlID: 35, IlADI: "İZMİR", SecimCEVRESIID: 404600, SecilecekADAYSAYISI: 14, Id: "35"}
+	cankaya := src.Ilce{IlceID: 815, IlceADI: "ÇANKAYA", BirimID: 3744, IlID: 6, IlADI: "ANKARA",
+		SecimCEVRESIID: 404520}
+	sincan := src.Ilce{IlceID: 816, IlceADI: "SİNCAN", BirimID: 3745, IlID: 6, IlADI: "ANKARA",
+		SecimCEVRESIID: 404520}
+	konak := src.Ilce{IlceID: 901, IlceADI: "KONAK", BirimID: 4001, IlID: 35, IlADI: "İZMİR",
+		SecimCEVRESIID: 404600}
+	almanya := src.Ulke{UlkeID: 9988, UlkeADI: "ALMANYA"}
+	berlin := src.DisTemsilcilik{DisTEMSILCILIKID: 14, DisTEMSILCILIKADI: "BERLIN BK", UlkeID: 9988,
+		UlkeADI: "ALMANYA", MinSANDIKNO: "1", MaxSANDIKNO: "1"}
+	kapikule := src.Gumruk{GumrukID: 7, GumrukADI: "KAPIKULE", IlceID: 1101, MinSANDIKNO: "1", MaxSANDIKNO: "1"}
+
+	basliklar := []src.SecimSonucBaslik{
+		{SiraNO: 1, Ad: "CUMHUR İTTİFAKI", ColumnNAME: "ittifak_1"},
+		{SiraNO: 2, Ad: "A PARTİSİ", ColumnNAME: "parti_1"},
+		{SiraNO: 3, Ad: "B PARTİSİ", ColumnNAME: "parti_2"},
+		{SiraNO: 4, Ad: "BAĞIMSIZ X", ColumnNAME: "bagimsiz_1"},
+	}
+	sandik := func(id, no int, il src.Il, ilce src.Ilce, oylar ...int) map[string]any {
+		row := map[string]any{
+			src.KeySandikID: id, src.KeySandikNO: no, src.KeySandikRUMUZ: nil,
+			src.KeyIlID: il.IlID, src.KeyIlADI: il.IlADI, src.KeyIlceID: ilce.IlceID, src.KeyIlceADI: ilce.IlceADI,
+			src.KeyMuhtarlikID: 100 + id, src.KeyMuhtarlikADI: fmt.Sprintf("MAHALLE %d", id),
+			src.KeySecmenSAYISI: 300, src.KeyOyKULLANAN: 250, src.KeyGecersizOY: 5,
+		}
+		toplam := 0
+		for i, b := range basliklar {
+			if i < len(oylar) {
+				row[b.ColumnNAME] = oylar[i]
+				toplam += oylar[i]
+			}
+		}
+		row[src.KeyGecerliOY] = toplam
+		return row
+	}
+	yurtdisi := src.Il{IlADI: "YURT DIŞI"}
+
+	birim := func(id int, ad, turu string, acilan int, alt ...src.DVOData) src.DVOData {
+		return src.DVOData{
+			BirimID: id, BirimADI: ad, Turu: turu, Version: fmt.Sprintf("v%d", acilan),
+			ToplamSandikSayisi: 10, AcilanSandikSayisi: acilan, KayitliSecmenSayisi: 3000,
+			OyKullananSecmenSayisi: 2500, GecerliOyToplami: 2400, GecersizOyToplami: 100,
+			PartiDVOs: []src.PartiDVOData{
+				{PartiAdi: "A PARTİSİ", PartiKisaAdi: "A", PartiSira: 1, Oy: 1400},
+				{PartiAdi: "B PARTİSİ", PartiKisaAdi: "B", PartiSira: 2, Oy: 1000},
+			},
+			AltBirimDVOs: alt,
+		}
+	}
+	cevAnkara := birim(ankara.SecimCEVRESIID, "ANKARA 1", "SECIM_CEVRESI", 3,
+		birim(cankaya.BirimID, cankaya.IlceADI, "ILCE", 2), birim(sincan.BirimID, sincan.IlceADI, "ILCE", 1))
+	cevIzmir := birim(izmir.SecimCEVRESIID, "İZMİR 1", "SECIM_CEVRESI", 1,
+		birim(konak.BirimID, konak.IlceADI, "ILCE", 1))
+
+	return &Fixture{
+		Iller: map[int][]src.Il{
+			src.SandikTuruIlce:    {ankara, izmir},
+			src.SandikTuruCezaevi: {ankara},
+		},
+		Ilceler: map[int][]src.Ilce{
+			ankara.SecimCEVRESIID: {cankaya, sincan},
+			izmir.SecimCEVRESIID:  {konak},
+		},
+		Muhtarliklar: map[int][]src.Muh{
+			cankaya.IlceID: {{MuhtarlikID: 101, MuhtarlikADI: "MAHALLE 1", IlceID: cankaya.IlceID}},
+		},
+		Ulkeler:           []src.Ulke{almanya},
+		DisTemsilcilikler: map[int][]src.DisTemsilcilik{almanya.UlkeID: {berlin}},
+		Gumrukler:         []src.Gumruk{kapikule},
+		Basliklar: map[int][]src.SecimSonucBaslik{
+			0:                     basliklar,
+			ankara.SecimCEVRESIID: basliklar,
+			izmir.SecimCEVRESIID:  basliklar[:3],
+		},
+		Sandiklar: map[string][]map[string]any{
+			SandikKey(src.SandikTuruIlce, cankaya.IlceID): {
+				sandik(1, 1, ankara, cankaya, 120, 60, 40, 10),
+				sandik(2, 2, ankara, cankaya, 110, 70, 30, 20),
+			},
+			SandikKey(src.SandikTuruIlce, sincan.IlceID): {sandik(3, 1, ankara, sincan, 100, 80, 40, 5)},
+			SandikKey(src.SandikTuruIlce, konak.IlceID):  {sandik(4, 1, izmir, konak, 90, 90, 50)},
+			SandikKey(src.SandikTuruCezaevi, cankaya.IlceID): {
+				sandik(5, 1001, ankara, cankaya, 20, 10, 5, 1),
+			},
+			SandikKey(src.SandikTuruDisTemsilcilik, berlin.DisTEMSILCILIKID): {
+				sandik(6, 1, yurtdisi, src.Ilce{IlceADI: berlin.DisTEMSILCILIKADI}, 200, 150, 100, 0),
+			},
+			SandikKey(src.SandikTuruGumruk, kapikule.GumrukID): {
+				sandik(7, 1, yurtdisi, src.Ilce{IlceID: kapikule.IlceID, IlceADI: kapikule.GumrukADI}, 30, 20, 10),
+			},
+		},
+		MV: src.MVSonuc{
+			Turkiye:  birim(1, "TÜRKİYE", "ULKE", 4, cevAnkara, cevIzmir),
+			Yurtdisi: birim(2, "YURT DIŞI", "YURTDISI", 2),
+		},
+		Cevreler: map[int]src.DVOData{
+			ankara.SecimCEVRESIID: cevAnkara,
+			izmir.SecimCEVRESIID:  cevIzmir,
+		},
+	}
+}
+
+// endregion
@@ -0,0 +1,192 @@
+package testserver
+
+import (
+	"encoding/json"
+	"github.com/secim/src"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// region Server
+
+// Fault is an injected failure mode for an endpoint.
+type Fault int
+
+const (
+	// FaultStatus answers with 500 Internal Server Error.
+	FaultStatus Fault = iota
+	// FaultMalformed answers 200 with a truncated JSON body.
+	FaultMalformed
+	// FaultEmpty answers 200 with an empty body.
+	FaultEmpty
+	// FaultNotFound answers with 404 Not Found.
+	FaultNotFound
+)
+
+// Server is a fake kokpit API serving a Fixture over HTTP. Point the fetcher
+// at it with client.Redirect or the -api-url flag.
+type Server struct {
+	*httptest.Server
+	fixture *Fixture
+
+	mu       sync.Mutex
+	latency  time.Duration
+	faults   map[string][]Fault
+	requests map[string]int
+}
+
+// New starts a server for f; call Close when done.
+func New(f *Fixture) *Server {
+	s := &Server{fixture: f, faults: make(map[string][]Fault), requests: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// SetLatency delays every response by d.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// Inject makes the next n requests to endpoint fail with fault. endpoint is
+// the last path element for ssps endpoints (e.g. getIlList) and
+// "indexpagedata" or "SECIM_CEVRESI" for the milletvekili ones.
+func (s *Server) Inject(endpoint string, fault Fault, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.faults[endpoint] = append(s.faults[endpoint], fault)
+	}
+}
+
+// Update changes the fixture while the server is running, e.g. to move the
+// milletvekili counters on. fn must replace lists and values rather than
+// change the elements of lists already served.
+func (s *Server) Update(fn func(*Fixture)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.fixture)
+}
+
+// Requests returns how many requests endpoint received, faults included.
+func (s *Server) Requests(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[endpoint]
+}
+
+// take counts the request and pops the next injected fault.
+func (s *Server) take(endpoint string) (time.Duration, *Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[endpoint]++
+	q := s.faults[endpoint]
+	if len(q) == 0 {
+		return s.latency, nil
+	}
+	s.faults[endpoint] = q[1:]
+	return s.latency, &q[0]
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	endpoint, id := path[strings.LastIndex(path, "/")+1:], 0
+	if strings.HasPrefix(path, "api/milletvekili/birim/") {
+		// .../birim/SECIM_CEVRESI/<id>
+		parts := strings.Split(path, "/")
+		endpoint = parts[len(parts)-2]
+		id, _ = strconv.Atoi(parts[len(parts)-1])
+	}
+	latency, fault := s.take(endpoint)
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if fault != nil {
+		switch *fault {
+		case FaultStatus:
+			http.Error(w, "injected failure", http.StatusInternalServerError)
+		case FaultNotFound:
+			http.NotFound(w, r)
+		case FaultMalformed:
+			_, _ = w.Write([]byte(`[{"broken": `))
+		}
+		return
+	}
+	body, ok := s.response(endpoint, id, r.URL.Query())
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// response selects the fixture list for an endpoint and its query.
+func (s *Server) response(endpoint string, id int, q map[string][]string) (any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := s.fixture
+	param := func(k string) int {
+		if v := q[k]; len(v) != 0 {
+			n, _ := strconv.Atoi(v[0])
+			return n
+		}
+		return 0
+	}
+	switch endpoint {
+	case "getIlList":
+		return nonNil(f.Iller[param("sandikTuru")]), true
+	case "getIlceList":
+		return nonNil(f.Ilceler[param("secimCevresiId")]), true
+	case "getMuhtarlikList":
+		return nonNil(f.Muhtarliklar[param("ilceId")]), true
+	case "getUlkeList":
+		return nonNil(f.Ulkeler), true
+	case "getDisTemsilcilikList":
+		return nonNil(f.DisTemsilcilikler[param("ulkeId")]), true
+	case "getGumrukList":
+		return nonNil(f.Gumrukler), true
+	case "getSandikSecimSonucBaslikList":
+		if param("yurtIciDisi") == 2 {
+			return nonNil(f.Basliklar[0]), true
+		}
+		return nonNil(f.Basliklar[param("secimCevresiId")]), true
+	case "getSecimSandikSonucList":
+		tur := param("sandikTuru")
+		key := 0
+		switch tur {
+		case src.SandikTuruIlce, src.SandikTuruCezaevi:
+			key = param("ilceId")
+		case src.SandikTuruGumruk:
+			key = param("gumrukId")
+		case src.SandikTuruDisTemsilcilik:
+			key = param("disTemsilcilikId")
+		}
+		return nonNil(f.Sandiklar[SandikKey(tur, key)]), true
+	case "indexpagedata":
+		return f.MV, true
+	case "SECIM_CEVRESI":
+		d, ok := f.Cevreler[id]
+		return d, ok
+	}
+	return nil, false
+}
+
+// nonNil keeps empty lists as [] instead of null, like the real API.
+func nonNil[T any](l []T) []T {
+	if l == nil {
+		return []T{}
+	}
+	return l
+}
+
+// endregion
@@ -0,0 +1,98 @@
+package testserver
+
+import (
+	"context"
+	"github.com/secim/src"
+	"github.com/secim/src/client"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func newClient(t *testing.T, s *Server) client.Client {
+	t.Helper()
+	d, err := client.Redirect(http.DefaultClient, s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client.From(d)
+}
+
+func TestServesFixture(t *testing.T) {
+	f := Default()
+	s := New(f)
+	defer s.Close()
+	c, ctx := newClient(t, s), context.Background()
+
+	iller := src.IlListesi(ctx, c, 8, 0)
+	if len(iller) != len(f.Iller[src.SandikTuruIlce]) {
+		t.Fatalf("got %d iller, want %d", len(iller), len(f.Iller[src.SandikTuruIlce]))
+	}
+	n := 0
+	for _, il := range iller {
+		for _, ilce := range src.IlceListesi(ctx, c, il, 8, 0) {
+			n += len(src.SandikSonuclari(ctx, c, src.IlceSonucParams(ilce, 8)))
+		}
+	}
+	if want := f.SandikCount(src.SandikTuruIlce); n != want {
+		t.Errorf("got %d sandik, want %d", n, want)
+	}
+	if mv := src.GenelMVSonuclar(ctx, c); mv.Turkiye.BirimADI != f.MV.Turkiye.BirimADI {
+		t.Errorf("indexpagedata: got %q", mv.Turkiye.BirimADI)
+	}
+	id := iller[0].SecimCEVRESIID
+	if d := src.CevreMVSonuclar(ctx, c, id); d.BirimID != f.Cevreler[id].BirimID {
+		t.Errorf("birim: got %d, want %d", d.BirimID, f.Cevreler[id].BirimID)
+	}
+}
+
+func TestFaultsAreRetried(t *testing.T) {
+	s := New(Default())
+	defer s.Close()
+	c, ctx := newClient(t, s), context.Background()
+
+	s.Inject("getUlkeList", FaultStatus, 1)
+	s.Inject("getUlkeList", FaultMalformed, 1)
+	s.Inject("getUlkeList", FaultEmpty, 1)
+	if l := src.UlkeListesi(ctx, c); len(l) != 1 {
+		t.Fatalf("got %d ulke, want 1", len(l))
+	}
+	if n := s.Requests("getUlkeList"); n != 4 {
+		t.Errorf("got %d requests, want 4", n)
+	}
+}
+
+func TestClientErrorsAreNotRetried(t *testing.T) {
+	s := New(Default())
+	defer s.Close()
+	c := newClient(t, s)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s.Inject("getGumrukList", FaultNotFound, 1)
+	_, err := src.Get[[]src.Gumruk](ctx, c, "getGumrukList", src.Params{"secimId": src.SecimID})
+	if err == nil || !client.IsPermanent(err) {
+		t.Fatalf("got %v, want a permanent error", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("404 was retried until the deadline")
+	}
+	if n := s.Requests("getGumrukList"); n != 1 {
+		t.Errorf("got %d requests, want 1", n)
+	}
+}
+
+func TestCancelDuringLatency(t *testing.T) {
+	s := New(Default())
+	defer s.Close()
+	s.SetLatency(time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	defer func() {
+		if _, ok := recover().(src.Canceled); !ok {
+			t.Error("MustGet did not panic with Canceled")
+		}
+	}()
+	src.GumrukListesi(ctx, newClient(t, s))
+}
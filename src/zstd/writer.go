@@ -0,0 +1,352 @@
+package zstd
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/bits"
+)
+
+// region Writer
+
+// Writer compresses to a single zstd frame with a content checksum. It is a
+// greedy LZ77 matcher within each 128KB block, with raw literals and the
+// predefined FSE tables of RFC 8878 for the sequences; blocks that do not get
+// smaller are stored raw. The ratio is well below the zstd command's, but it
+// needs no cgo or external command and every stream is readable by any zstd
+// decoder.
+type Writer struct {
+	w      io.Writer
+	buf    []byte
+	hash   xxhash64
+	header bool
+	closed bool
+	err    error
+	enc    blockEncoder
+}
+
+const (
+	blockSize = 128 << 10
+	// window descriptor for a 128KB window: exponent 7, mantissa 0
+	windowDescriptor = 7 << 3
+	// frame header descriptor: no content size, no dictionary, checksum
+	frameDescriptor = 1 << 2
+	minMatch        = 4
+	hashLog         = 15
+)
+
+var errClosed = errors.New("zstd: write to closed Writer")
+
+// NewWriter returns a Writer compressing to w. Close must be called to
+// finish the frame; it does not close w.
+func NewWriter(w io.Writer) *Writer {
+	z := &Writer{w: w, buf: make([]byte, 0, blockSize)}
+	z.hash.reset()
+	return z
+}
+
+func (z *Writer) Write(p []byte) (int, error) {
+	if z.closed {
+		return 0, errClosed
+	}
+	if z.err != nil {
+		return 0, z.err
+	}
+	n := len(p)
+	z.hash.update(p)
+	for len(p) != 0 {
+		if len(z.buf) == blockSize {
+			// the last block is only known at Close
+			if z.err = z.writeBlock(z.buf, false); z.err != nil {
+				return 0, z.err
+			}
+			z.buf = z.buf[:0]
+		}
+		k := copy(z.buf[len(z.buf):blockSize], p)
+		z.buf = z.buf[:len(z.buf)+k]
+		p = p[k:]
+	}
+	return n, nil
+}
+
+// Close writes the buffered data as the last block and the checksum.
+func (z *Writer) Close() error {
+	if z.closed {
+		return z.err
+	}
+	z.closed = true
+	if z.err != nil {
+		return z.err
+	}
+	if z.err = z.writeBlock(z.buf, true); z.err != nil {
+		return z.err
+	}
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], uint32(z.hash.digest()))
+	_, z.err = z.w.Write(sum[:])
+	return z.err
+}
+
+func (z *Writer) writeBlock(src []byte, last bool) error {
+	out := z.enc.out[:0]
+	if !z.header {
+		z.header = true
+		// magic number 0xfd2fb528
+		out = append(out, 0x28, 0xb5, 0x2f, 0xfd, frameDescriptor, windowDescriptor)
+	}
+	// RFC 3.1.1.2: Last_Block, Block_Type (0 raw, 2 compressed), Block_Size
+	hdr := len(out)
+	out = append(out, 0, 0, 0)
+	typ := 0
+	if len(src) != 0 {
+		if n := len(out); z.enc.compress(src, &out) && len(out)-n < len(src) {
+			typ = 2
+		} else {
+			out = append(out[:n], src...)
+		}
+	}
+	h := uint32(len(out)-hdr-3)<<3 | uint32(typ)<<1
+	if last {
+		h |= 1
+	}
+	out[hdr], out[hdr+1], out[hdr+2] = byte(h), byte(h>>8), byte(h>>16)
+	z.enc.out = out
+	_, err := z.w.Write(out)
+	return err
+}
+
+// endregion
+// region blockEncoder
+
+type sequence struct {
+	lit, match, offset uint32
+}
+
+type blockEncoder struct {
+	table [1 << hashLog]int32
+	lits  []byte
+	seqs  []sequence
+	out   []byte
+}
+
+func load32(b []byte, i int) uint32 {
+	return binary.LittleEndian.Uint32(b[i:])
+}
+
+func hash4(v uint32) uint32 {
+	return (v * 2654435761) >> (32 - hashLog)
+}
+
+// compress appends the compressed block content for src to out; false if
+// src has no matches worth a compressed block.
+func (e *blockEncoder) compress(src []byte, out *[]byte) bool {
+	e.lits, e.seqs = e.lits[:0], e.seqs[:0]
+	for i := range e.table {
+		e.table[i] = 0
+	}
+	anchor := 0
+	for i := 0; i+minMatch <= len(src); {
+		v := load32(src, i)
+		h := hash4(v)
+		cand := int(e.table[h]) - 1
+		e.table[h] = int32(i + 1)
+		if cand < 0 || load32(src, cand) != v {
+			i++
+			continue
+		}
+		n := minMatch
+		for i+n < len(src) && src[cand+n] == src[i+n] {
+			n++
+		}
+		for i > anchor && cand > 0 && src[i-1] == src[cand-1] {
+			i, cand, n = i-1, cand-1, n+1
+		}
+		e.lits = append(e.lits, src[anchor:i]...)
+		e.seqs = append(e.seqs, sequence{lit: uint32(i - anchor), match: uint32(n), offset: uint32(i - cand)})
+		i += n
+		anchor = i
+	}
+	if len(e.seqs) == 0 {
+		return false
+	}
+	e.lits = append(e.lits, src[anchor:]...)
+	*out = e.appendLiterals(*out)
+	*out = e.appendSequences(*out)
+	return true
+}
+
+// appendLiterals writes a Raw_Literals_Block, RFC 3.1.1.3.1.
+func (e *blockEncoder) appendLiterals(out []byte) []byte {
+	n := len(e.lits)
+	switch {
+	case n < 1<<5:
+		out = append(out, byte(n<<3))
+	case n < 1<<12:
+		out = append(out, byte(1<<2|n<<4), byte(n>>4))
+	default:
+		out = append(out, byte(3<<2|n<<4), byte(n>>4), byte(n>>12))
+	}
+	return append(out, e.lits...)
+}
+
+// appendSequences writes the Sequences_Section with the predefined tables,
+// RFC 3.1.1.3.2. The bitstream is read backwards, so the sequences are
+// written last to first, each field in the reverse of the decoding order.
+func (e *blockEncoder) appendSequences(out []byte) []byte {
+	n := len(e.seqs)
+	switch {
+	case n < 128:
+		out = append(out, byte(n))
+	case n < 0x7f00:
+		out = append(out, byte(n>>8)+0x80, byte(n))
+	default:
+		out = append(out, 0xff, byte(n-0x7f00), byte((n-0x7f00)>>8))
+	}
+	// Literals_Lengths, Offsets and Match_Lengths all Predefined_Mode
+	out = append(out, 0)
+
+	ll, of, ml := encoders()
+	bw := bitWriter{out: out}
+	var llState, ofState, mlState uint16
+	for i := n - 1; i >= 0; i-- {
+		s := e.seqs[i]
+		// repeat offsets are never used, so the decoder's offset table
+		// yields the offset itself
+		llSym, ofSym, mlSym := ll.symbol(s.lit), of.symbol(s.offset), ml.symbol(s.match)
+		if i == n-1 {
+			llState, ofState, mlState = llSym.states[0], ofSym.states[0], mlSym.states[0]
+		} else {
+			// state updates are decoded literal, match, offset
+			ofState = ofSym.transition(of, ofState, &bw)
+			mlState = mlSym.transition(ml, mlState, &bw)
+			llState = llSym.transition(ll, llState, &bw)
+		}
+		// extra bits are decoded offset, match, literal
+		bw.add(s.lit-llSym.baseline, llSym.basebits)
+		bw.add(s.match-mlSym.baseline, mlSym.basebits)
+		bw.add(s.offset-ofSym.baseline, ofSym.basebits)
+	}
+	// initial states are decoded literal, offset, match
+	bw.add(uint32(mlState), ml.tableBits)
+	bw.add(uint32(ofState), of.tableBits)
+	bw.add(uint32(llState), ll.tableBits)
+	return bw.close()
+}
+
+// endregion
+// region FSE
+
+// fseSymbol is one value range of a predefined table: the values in
+// [baseline, baseline + 1<<basebits). states maps the decoder's next state
+// to the state that yields this symbol and leads there.
+type fseSymbol struct {
+	baseline uint32
+	basebits uint8
+	states   []uint16
+}
+
+// transition returns the state for s that leads to next, writing the bits
+// the decoder reads to get from that state to next.
+func (s *fseSymbol) transition(t *fseEncoder, next uint16, bw *bitWriter) uint16 {
+	state := s.states[next]
+	e := t.table[state]
+	bw.add(uint32(next-e.base), e.bits)
+	return state
+}
+
+// fseEncoder inverts one of the decoder's predefined baseline tables.
+type fseEncoder struct {
+	table     []fseBaselineEntry
+	tableBits uint8
+	symbols   []*fseSymbol
+	// small maps the values below len(small) directly to their symbol
+	small []*fseSymbol
+}
+
+func newFSEEncoder(table []fseBaselineEntry, offsets bool) *fseEncoder {
+	t := &fseEncoder{table: table, tableBits: uint8(bits.Len(uint(len(table))) - 1)}
+	byKey := make(map[uint64]*fseSymbol)
+	for i, e := range table {
+		if offsets && e.basebits <= 1 {
+			// repeat offset codes
+			continue
+		}
+		k := uint64(e.baseline) | uint64(e.basebits)<<32
+		s, ok := byKey[k]
+		if !ok {
+			s = &fseSymbol{baseline: e.baseline, basebits: e.basebits, states: make([]uint16, len(table))}
+			byKey[k] = s
+			t.symbols = append(t.symbols, s)
+		}
+		for next := int(e.base); next < int(e.base)+1<<e.bits; next++ {
+			s.states[next] = uint16(i)
+		}
+	}
+	t.small = make([]*fseSymbol, 64)
+	for v := range t.small {
+		t.small[v] = t.find(uint32(v))
+	}
+	return t
+}
+
+func (t *fseEncoder) find(v uint32) *fseSymbol {
+	for _, s := range t.symbols {
+		if v >= s.baseline && v-s.baseline < 1<<s.basebits {
+			return s
+		}
+	}
+	return nil
+}
+
+func (t *fseEncoder) symbol(v uint32) *fseSymbol {
+	if v < uint32(len(t.small)) {
+		return t.small[v]
+	}
+	return t.find(v)
+}
+
+var encoderTables struct {
+	ll, of, ml *fseEncoder
+}
+
+func init() {
+	encoderTables.ll = newFSEEncoder(predefinedLiteralTable[:], false)
+	encoderTables.of = newFSEEncoder(predefinedOffsetTable[:], true)
+	encoderTables.ml = newFSEEncoder(predefinedMatchTable[:], false)
+}
+
+func encoders() (ll, of, ml *fseEncoder) {
+	return encoderTables.ll, encoderTables.of, encoderTables.ml
+}
+
+// endregion
+// region bitWriter
+
+// bitWriter appends bits from the least significant end; the decoder's
+// reverseBitReader reads them back last to first.
+type bitWriter struct {
+	out []byte
+	acc uint64
+	n   uint8
+}
+
+func (bw *bitWriter) add(v uint32, n uint8) {
+	bw.acc |= uint64(v) << bw.n
+	bw.n += n
+	for bw.n >= 8 {
+		bw.out = append(bw.out, byte(bw.acc))
+		bw.acc >>= 8
+		bw.n -= 8
+	}
+}
+
+// close ends the stream with the 1 bit the decoder starts from.
+func (bw *bitWriter) close() []byte {
+	bw.add(1, 1)
+	if bw.n > 0 {
+		bw.out = append(bw.out, byte(bw.acc))
+	}
+	return bw.out
+}
+
+// endregion
@@ -0,0 +1,106 @@
+package zstd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func compress(t *testing.T, data []byte, chunk int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	z := NewWriter(&buf)
+	for p := data; len(p) != 0; {
+		n := chunk
+		if n > len(p) {
+			n = len(p)
+		}
+		if _, err := z.Write(p[:n]); err != nil {
+			t.Fatal(err)
+		}
+		p = p[n:]
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	var csv bytes.Buffer
+	for i := 0; csv.Len() < 3*blockSize+1234; i++ {
+		fmt.Fprintf(&csv, "60792/8/%d/%d,\"ANKARA\",\"ÇANKAYA\",%d,%d,%d\n", i/7, i, 300+i%13, 250+i%11, i%97)
+	}
+	random := make([]byte, blockSize+17)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	for _, tc := range []struct {
+		name  string
+		data  []byte
+		chunk int
+		// the compressed size must be below this share of the input
+		ratio float64
+	}{
+		{"empty", nil, 1, 0},
+		{"short", []byte("a,b\n"), 1, 0},
+		{"repeat", bytes.Repeat([]byte("x"), 1000), 7, 0.1},
+		{"csv", csv.Bytes(), 4096, 0.5},
+		{"csvOneWrite", csv.Bytes(), csv.Len(), 0.5},
+		{"exactBlock", csv.Bytes()[:blockSize], 1 << 20, 0.5},
+		{"random", random, 1000, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := compress(t, tc.data, tc.chunk)
+			got, err := io.ReadAll(NewReader(bytes.NewReader(c)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tc.data) {
+				t.Fatalf("round trip changed the data: got %d bytes, want %d", len(got), len(tc.data))
+			}
+			if tc.ratio != 0 && float64(len(c)) > tc.ratio*float64(len(tc.data)) {
+				t.Errorf("compressed %d bytes to %d", len(tc.data), len(c))
+			}
+		})
+	}
+}
+
+// every value of a symbol's range must be reachable from every next state
+// through a state of that symbol
+func TestEncoderTables(t *testing.T) {
+	ll, of, ml := encoders()
+	for _, tc := range []struct {
+		name string
+		t    *fseEncoder
+		max  uint32
+	}{{"literal", ll, blockSize - minMatch}, {"offset", of, blockSize - minMatch}, {"match", ml, blockSize}} {
+		for v := uint32(1); v <= tc.max; v++ {
+			if tc.name == "match" && v < 3 {
+				continue
+			}
+			s := tc.t.symbol(v)
+			if s == nil {
+				t.Fatalf("%s: no symbol for %d", tc.name, v)
+			}
+			for next, state := range s.states {
+				e := tc.t.table[state]
+				if e.baseline != s.baseline || e.basebits != s.basebits ||
+					next < int(e.base) || next >= int(e.base)+1<<e.bits {
+					t.Fatalf("%s %d: state %d does not lead to %d", tc.name, v, state, next)
+				}
+			}
+		}
+	}
+}
+
+func TestWriterClosed(t *testing.T) {
+	z := NewWriter(io.Discard)
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := z.Write([]byte("x")); err == nil {
+		t.Error("write after Close succeeded")
+	}
+}
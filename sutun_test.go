@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"github.com/secim/src"
+	"github.com/secim/src/registry"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "testdata altindaki golden dosyalari guncelle")
+
+// golden sutun testleri icin baslik listesi; bilerek karisik sirada
+var goldenBasliklar = []src.SecimSonucBaslik{
+	{SiraNO: 2, Ad: "B PARTİSİ", ColumnNAME: "parti_2"},
+	{SiraNO: 1, Ad: "BAĞIMSIZ X", ColumnNAME: "bagimsiz_1"},
+	{SiraNO: 1, Ad: "A PARTİSİ", ColumnNAME: "parti_1"},
+	// ayni sira no; ada gore siralanmali
+	{SiraNO: 2, Ad: "AA PARTİSİ", ColumnNAME: "parti_3"},
+	{SiraNO: 0, Ad: "GİZLİ ADAY", ColumnNAME: "bagimsiz_9"},
+	{SiraNO: 1, Ad: "CUMHUR İTTİFAKI", ColumnNAME: "ittifak_1"},
+}
+
+// api'den gelen satirlar; baslik listesinde olmayan alanlar SiraNO 9999 ile
+// uydurulur. SiraNO 0 olan basligin degeri de bu yuzden column name'inden
+// uydurulan sutuna duser (BAGIMSIZ 9) ve mv'de bagimsiz olarak atlanir.
+var goldenSatirlar = []map[string]any{
+	{"sandik_NO": float64(1), "il_ADI": "ANKARA", "ittifak_1": float64(120), "parti_1": float64(70),
+		"parti_2": float64(40), "parti_3": float64(10), "bagimsiz_1": float64(5), "bagimsiz_9": float64(3)},
+	{"sandik_NO": float64(2), "il_ADI": "ANKARA", "ittifak_1": float64(90), "parti_1": nil,
+		"parti_2": float64(30), "bagimsiz_1": float64(1), "ek_ALAN": "yeni \"deger\""},
+}
+
+// goldenKayit golden basliklari 404520 cevresine kaydeder
+func goldenKayit() *registry.Registry {
+	reg := registry.New()
+	reg.Add(404520, goldenBasliklar)
+	return reg
+}
+
+func goldenCSV(t *testing.T, isCB bool) []byte {
+	t.Helper()
+	reg := goldenKayit()
+	colNames := reg.Sutunlar(404520)
+	sb := SutunBilgi{Names: reg.Adlar(404520)}
+	rows := make([]map[string]any, 0, len(goldenSatirlar))
+	anahtarlar := make([]string, 0, len(goldenSatirlar))
+	q := src.IlceSonucParams(src.Ilce{IlID: 6, IlceID: 815, SecimCEVRESIID: 404520}, secimTurID(isCB))
+	for j, s := range goldenSatirlar {
+		rows = append(rows, sb.addRow(colNames, s))
+		anahtarlar = append(anahtarlar, q.Kimlik(s, j).String())
+	}
+	var buf bytes.Buffer
+	pc := sb.FprintHeader(&buf, "golden"+cbPrefix(isCB), skippedColumnsFn(isCB))
+	for i, row := range rows {
+		pc.FprintRow(&buf, anahtarlar[i], row)
+	}
+	return buf.Bytes()
+}
+
+func TestSutunSirasiGolden(t *testing.T) {
+	for _, isCB := range []bool{false, true} {
+		t.Run(cbPrefix(isCB), func(t *testing.T) {
+			got := goldenCSV(t, isCB)
+			fn := filepath.Join("testdata", "sandiklar"+cbPrefix(isCB)+".golden.csv")
+			if *update {
+				if err := os.WriteFile(fn, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s farkli (guncellemek icin -update):\n got:\n%s\nwant:\n%s", fn, got, want)
+			}
+		})
+	}
+}
+
+func TestAddRowUydurulanSutun(t *testing.T) {
+	reg := goldenKayit()
+	sb := SutunBilgi{Names: reg.Adlar(404520)}
+	row := sb.addRow(reg.Sutunlar(404520), map[string]any{"ek_ALAN": "x", "parti_1": 1})
+	col, ok := sb.Names["EK ALAN"]
+	if !ok {
+		t.Fatalf("uydurulan sutun eklenmedi: %v", sb.Names)
+	}
+	if col.SiraNO != 9999 || col.ColumnNAME != "ek_ALAN" {
+		t.Errorf("uydurulan sutun: %+v", col)
+	}
+	if row["EK ALAN"] != "x" || row["A PARTİSİ"] != 1 {
+		t.Errorf("satir adlarla anahtarlanmali: %v", row)
+	}
+	if _, ok := sb.Names["GİZLİ ADAY"]; ok {
+		t.Error("SiraNO 0 olan baslik atlanmali")
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/secim/src"
+	"github.com/secim/src/client"
+	"github.com/secim/src/logx"
+	"strconv"
+	"strings"
+)
+
+// tutanakKomutu tek bir sandigin veya bir sandik no / rumuz araliginin
+// sonuclarini ceker; gozlemciler butun ilceyi gezmeden tutanak kontrol edebilsin.
+// Birimler ad veya id ile secilir:
+//
+//	tutanak -il ANKARA -ilce CANKAYA -no 1001 -son 1005
+//	tutanak -ulke ALMANYA -temsilcilik "BERLIN BK" -rumuz 1001A
+//	tutanak -gumruk KAPIKULE -no 1
+func tutanakKomutu(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("tutanak", flag.ExitOnError)
+	newClient := clientFlags(fs)
+	isCB := fs.Bool("cb", false, "cumhurbaskanligi sonuclari (varsayilan mv)")
+	il := fs.String("il", "", "yurt ici: il adi veya id'si")
+	ilce := fs.String("ilce", "", "yurt ici: ilce adi veya id'si")
+	muhtarlik := fs.String("muhtarlik", "", "yurt ici: muhtarlik adi veya id'si (istege bagli)")
+	cezaevi := fs.Bool("cezaevi", false, "yurt ici: cezaevi sandiklari")
+	ulke := fs.String("ulke", "", "yurt disi: ulke adi veya id'si")
+	temsilcilik := fs.String("temsilcilik", "", "yurt disi: dis temsilcilik adi veya id'si")
+	gumruk := fs.String("gumruk", "", "gumruk kapisi adi veya id'si")
+	no := fs.Int("no", 0, "sandik no; -son ile araligin basi")
+	son := fs.Int("son", 0, "sandik no araliginin sonu (bos = sadece -no)")
+	rumuz := fs.String("rumuz", "", "yurt disi sandik rumuzu; -rumuz-son ile araligin basi")
+	rumuzSon := fs.String("rumuz-son", "", "rumuz araliginin sonu (bos = sadece -rumuz)")
+	sandikID := fs.Int("sandik-id", 0, "sandik id'si")
+	out := fs.String("o", "", "cikti dosyasi (bos = -name-template ile output/ altinda, - = stdout)")
+	setupOutput := outputFlags(fs)
+	parseFlags(fs, args)
+	setupOutput()
+	if *no == 0 && *rumuz == "" && *sandikID == 0 {
+		logx.Fatal("sandik secilmeli: -no, -rumuz veya -sandik-id")
+	}
+	if *son != 0 && *no == 0 {
+		logx.Fatal("-son sadece -no ile verilebilir")
+	}
+	if *rumuzSon != "" && *rumuz == "" {
+		logx.Fatal("-rumuz-son sadece -rumuz ile verilebilir")
+	}
+	c := newClient()
+	st := secimTurID(*isCB)
+
+	var t tutanakBirimi
+	switch {
+	case *gumruk != "":
+		t = gumrukBirimi(ctx, c, st, *gumruk)
+	case *ulke != "" || *temsilcilik != "":
+		t = temsilcilikBirimi(ctx, c, st, *ulke, *temsilcilik)
+	case *il != "" && *ilce != "":
+		t = ilceBirimi(ctx, c, st, *il, *ilce, *muhtarlik, *cezaevi)
+	default:
+		logx.Fatal("birim secilmeli: -il ve -ilce, -ulke ve -temsilcilik veya -gumruk")
+	}
+	q := t.q.Sandik(*sandikID)
+	if *no != 0 {
+		q = q.Aralik(*no, *son)
+		if t.aralik != nil && (!t.aralik.Icerir(q.SandikNoIlk) || !t.aralik.Icerir(q.SandikNoSon)) {
+			logx.Warn("Sandik no birimin araliginin disinda", logx.F("birim", t.ad),
+				logx.F("aralik", t.aralik), logx.F("no", *no), logx.F("son", q.SandikNoSon))
+		}
+	}
+	if *rumuz != "" {
+		q = q.RumuzAralik(*rumuz, *rumuzSon)
+		if t.rumuzAralik != nil && (!t.rumuzAralik.Icerir(q.SandikRumuzIlk) || !t.rumuzAralik.Icerir(q.SandikRumuzSon)) {
+			logx.Warn("Sandik rumuzu birimin araliginin disinda", logx.F("birim", t.ad),
+				logx.F("aralik", t.rumuzAralik), logx.F("rumuz", *rumuz), logx.F("son", q.SandikRumuzSon))
+		}
+	}
+	if err := q.Validate(); err != nil {
+		logx.Fatal("gecersiz sorgu", logx.F("params", q), logx.Err(err))
+	}
+
+	logx.Info("Sandik sonuclari cekiliyor", logx.F("birim", t.ad), logx.F("params", q))
+	rows := src.SandikAraligiSonuclari(ctx, c, q)
+	if len(rows) == 0 {
+		logx.Warn("Sandik bulunamadi", logx.F("birim", t.ad), logx.F("params", q))
+	}
+	reg := baslikKaydet(st, t.cevreID, t.basliklar)
+	sb := SutunBilgi{Names: reg.Adlar(t.cevreID)}
+	sutunlar := reg.Sutunlar(t.cevreID)
+	var satirlar []map[string]any
+	var anahtarlar []string
+	for j, row := range rows {
+		satirlar = append(satirlar, sb.addRow(sutunlar, row))
+		anahtarlar = append(anahtarlar, q.Kimlik(row, j).String())
+	}
+
+	w, fn, closeOut := openCikti(*out, "tutanak", *isCB, "csv")
+	pc := sb.FprintHeader(w, "tutanak"+cbPrefix(*isCB), skippedColumnsFn(*isCB))
+	for i, row := range satirlar {
+		pc.FprintRow(w, anahtarlar[i], row)
+	}
+	closeOut()
+	logx.Info("Tutanak sonuclari yazildi", logx.F("sandik", len(satirlar)), logx.F("dosya", fn))
+}
+
+// tutanakBirimi secilen birimin sorgusu, basliklari ve biliniyorsa sandik no /
+// rumuz araligi. Yurt disi birimlerinde cevreID registry.YurtdisiCevreID'dir.
+type tutanakBirimi struct {
+	ad          string
+	q           src.SandikSonucQuery
+	cevreID     int
+	basliklar   []src.SecimSonucBaslik
+	aralik      *src.SandikAraligi
+	rumuzAralik *src.RumuzAraligi
+}
+
+// eslesir aranan id ise id'yle, degilse buyuk / kucuk harf ve Turkce
+// karakter farki gozetmeden adla karsilastirir. "ISTANBUL" secim
+// cevrelerinden "İSTANBUL 1"i de tutar.
+func eslesir(aranan string, id int, ad string) bool {
+	if n, err := strconv.Atoi(aranan); err == nil {
+		return n == id
+	}
+	a, b := ilSlug(strings.TrimSpace(aranan)), ilSlug(ad)
+	return a == b || strings.HasPrefix(b, a+"_")
+}
+
+func araligi(a src.SandikAraligi, err error) *src.SandikAraligi {
+	if err != nil {
+		return nil
+	}
+	return &a
+}
+
+func rumuzAraligi(a src.RumuzAraligi, err error) *src.RumuzAraligi {
+	if err != nil {
+		return nil
+	}
+	return &a
+}
+
+func ilceBirimi(ctx context.Context, c client.Client, st int, il, ilce, muhtarlik string, cezaevi bool) tutanakBirimi {
+	sandikTuru := src.SandikTuruIlce
+	if cezaevi {
+		if muhtarlik != "" {
+			logx.Fatal("cezaevi sandiklari muhtarlikla secilemez")
+		}
+		sandikTuru = src.SandikTuruCezaevi
+	}
+	for _, cev := range src.IlListesi(ctx, c, st, sandikTuru) {
+		if !eslesir(il, cev.IlID, cev.IlADI) {
+			continue
+		}
+		for _, ic := range src.IlceListesi(ctx, c, cev, st, sandikTuru) {
+			if !eslesir(ilce, ic.IlceID, ic.IlceADI) {
+				continue
+			}
+			t := tutanakBirimi{ad: cev.IlADI + " / " + ic.IlceADI, cevreID: cev.SecimCEVRESIID,
+				basliklar: src.SecimSonucBaslikListesi(ctx, c, cev, st)}
+			if cezaevi {
+				t.q = src.CezaeviSonucParams(ic, st)
+			} else {
+				t.q = src.IlceSonucParams(ic, st)
+			}
+			if muhtarlik == "" {
+				return t
+			}
+			for _, m := range src.MuhtarlikListesi(ctx, c, ic, st, sandikTuru) {
+				if eslesir(muhtarlik, m.MuhtarlikID, m.MuhtarlikADI) {
+					t.ad += " / " + m.MuhtarlikADI
+					t.q = src.MuhtarlikSonucParams(ic, m, st)
+					t.aralik = araligi(m.SandikAraligi())
+					return t
+				}
+			}
+			logx.Fatal("muhtarlik bulunamadi", logx.F("ilce", t.ad), logx.F("muhtarlik", muhtarlik))
+		}
+	}
+	logx.Fatal("ilce bulunamadi", logx.F("il", il), logx.F("ilce", ilce), logx.F("cezaevi", cezaevi))
+	return tutanakBirimi{}
+}
+
+func temsilcilikBirimi(ctx context.Context, c client.Client, st int, ulke, temsilcilik string) tutanakBirimi {
+	if ulke == "" || temsilcilik == "" {
+		logx.Fatal("-ulke ve -temsilcilik birlikte verilmeli")
+	}
+	for _, u := range src.UlkeListesi(ctx, c) {
+		if !eslesir(ulke, u.UlkeID, u.UlkeADI) {
+			continue
+		}
+		for _, dt := range src.DisTemsilcilikListesi(ctx, c, u) {
+			if eslesir(temsilcilik, dt.DisTEMSILCILIKID, dt.DisTEMSILCILIKADI) {
+				return tutanakBirimi{ad: u.UlkeADI + " / " + dt.DisTEMSILCILIKADI,
+					q:           src.DisTemsSonucParams(dt, st),
+					basliklar:   src.YurtdisiSecimSonucBaslikListesi(ctx, c, st),
+					aralik:      araligi(dt.SandikAraligi()),
+					rumuzAralik: rumuzAraligi(dt.RumuzAraligi())}
+			}
+		}
+	}
+	logx.Fatal("dis temsilcilik bulunamadi", logx.F("ulke", ulke), logx.F("temsilcilik", temsilcilik))
+	return tutanakBirimi{}
+}
+
+func gumrukBirimi(ctx context.Context, c client.Client, st int, gumruk string) tutanakBirimi {
+	for _, g := range src.GumrukListesi(ctx, c) {
+		if eslesir(gumruk, g.GumrukID, g.GumrukADI) {
+			return tutanakBirimi{ad: g.GumrukADI,
+				q:           src.GumrukSonucParams(g, st),
+				basliklar:   src.YurtdisiSecimSonucBaslikListesi(ctx, c, st),
+				aralik:      araligi(g.SandikAraligi()),
+				rumuzAralik: rumuzAraligi(g.RumuzAraligi())}
+		}
+	}
+	logx.Fatal("gumruk kapisi bulunamadi", logx.F("gumruk", gumruk))
+	return tutanakBirimi{}
+}
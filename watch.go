@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/secim/src"
+	"github.com/secim/src/client"
+	"github.com/secim/src/logx"
+	"github.com/secim/src/registry"
+	"time"
+)
+
+// birimDurum bir birimin degisip degismedigini anlamak icin bakilan sayaclar
+type birimDurum struct {
+	Version            string
+	AcilanSandikSayisi int
+}
+
+func durumOf(d src.DVOData) birimDurum {
+	return birimDurum{Version: d.Version, AcilanSandikSayisi: d.AcilanSandikSayisi}
+}
+
+// genelDurum ana sayfanin Turkiye ve yurt disi sayaclari; biri degismeden
+// digeri degisebildigi icin ikisine birden bakilir
+type genelDurum struct {
+	Turkiye, Yurtdisi birimDurum
+}
+
+func genelDurumOf(m src.MVSonuc) genelDurum {
+	return genelDurum{Turkiye: durumOf(m.Turkiye), Yurtdisi: durumOf(m.Yurtdisi)}
+}
+
+// izleKomutu milletvekili sayaclarini periyodik olarak yoklar ve sadece
+// sayaclari degisen secim cevrelerinin sandik sonuclarini yeniden ceker.
+// Sayaclar cb icin de mv endpoint'inden okunur; cb sonuclari ayni sandiklardan sayilir.
+func izleKomutu(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	newClient := clientFlags(fs)
+	serveMetrics := metricsFlags(fs)
+	interval := fs.Duration("interval", 2*time.Minute, "yoklama araligi")
+	isCB := fs.Bool("cb", false, "cumhurbaskanligi sandiklarini cek (varsayilan mv)")
+	setupOutput := outputFlags(fs)
+	parseFlags(fs, args)
+	if *interval < time.Minute {
+		// dosya adlarindaki zaman damgasi dakika cozunurlugunde
+		logx.Fatal("yoklama araligi en az 1 dakika olmali", logx.F("interval", *interval))
+	}
+	setupOutput()
+	serveMetrics()
+	c := newClient()
+
+	kosu.kapsamEkle("izle" + cbPrefix(*isCB))
+	iz := newIzleyici(ctx, c, *isCB)
+	for tur := 1; ; tur++ {
+		iz.yokla(ctx, tur)
+		select {
+		case <-time.After(*interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// izleyici watch komutunun turlar arasinda sakladigi sayaclar
+type izleyici struct {
+	c        client.Client
+	isCB     bool
+	cevreler []src.Il
+	reg      *registry.Registry
+	lg       *logx.Logger
+	// genel onceki turun genel sayaclari; ilk turdan once nil
+	genel    *genelDurum
+	durumlar map[int]birimDurum
+}
+
+func newIzleyici(ctx context.Context, c client.Client, isCB bool) *izleyici {
+	st := secimTurID(isCB)
+	cevreler := src.IlListesi(ctx, c, st, 0)
+	for _, cvr := range cevreler {
+		baslikKaydet(st, cvr.SecimCEVRESIID, src.SecimSonucBaslikListesi(ctx, c, cvr, st))
+	}
+	return &izleyici{c: c, isCB: isCB, cevreler: cevreler, reg: kosu.baslikKaydi(st),
+		lg: scopeLogger("izle", isCB), durumlar: make(map[int]birimDurum)}
+}
+
+// yokla genel sayaclara bakar. Turkiye sayaclari degistiyse secim cevrelerinin
+// sayaclarini tek tek yoklayip degisenleri, yurt disi sayaclari degistiyse
+// dis temsilcilik ve gumruk sandiklarini yeniden ceker. Yurt disi sayaclari
+// ulke / gumruk kirilimi vermedigi icin iki kapsam da butun olarak cekilir.
+func (iz *izleyici) yokla(ctx context.Context, tur int) {
+	g := genelDurumOf(src.GenelMVSonuclar(ctx, iz.c))
+	if iz.genel != nil && *iz.genel == g {
+		iz.lg.Info("Genel sayaclar degismedi", logx.F("tur", tur), logx.F("acilanSandik", g.Turkiye.AcilanSandikSayisi),
+			logx.F("yurtdisiAcilanSandik", g.Yurtdisi.AcilanSandikSayisi), logx.F("mem", memUsage()))
+		return
+	}
+	if iz.genel == nil || iz.genel.Turkiye != g.Turkiye {
+		degisen := 0
+		for _, cev := range iz.cevreler {
+			d := durumOf(src.CevreMVSonuclar(ctx, iz.c, cev.SecimCEVRESIID))
+			if eski, ok := iz.durumlar[cev.SecimCEVRESIID]; ok && eski == d {
+				continue
+			}
+			degisen++
+			iz.lg.Info("Secim cevresi degisti, sandiklar cekiliyor", logx.F("tur", tur), logx.F("il", cev.IlADI),
+				logx.F("acilanSandik", d.AcilanSandikSayisi), logx.F("version", d.Version), logx.F("mem", memUsage()))
+			izleCevreSnapshot(ctx, iz.c, cev, iz.reg, iz.isCB)
+			iz.durumlar[cev.SecimCEVRESIID] = d
+		}
+		iz.lg.Info("Secim cevreleri guncellendi", logx.F("tur", tur),
+			logx.F("degisen", degisen), logx.F("toplam", len(iz.cevreler)), logx.F("mem", memUsage()))
+	}
+	if iz.genel == nil || iz.genel.Yurtdisi != g.Yurtdisi {
+		iz.lg.Info("Yurt disi sayaclari degisti, sandiklar cekiliyor", logx.F("tur", tur),
+			logx.F("acilanSandik", g.Yurtdisi.AcilanSandikSayisi), logx.F("version", g.Yurtdisi.Version),
+			logx.F("mem", memUsage()))
+		izleKapsamSnapshot[src.Ulke](ctx, iz.c, disTemsKapsami{}, iz.isCB)
+		izleKapsamSnapshot[src.Gumruk](ctx, iz.c, gumrukKapsami{}, iz.isCB)
+	}
+	iz.genel = &g
+}
+
+// izleCevreSnapshot tek bir secim cevresinin sandik sonuclarini zaman damgali dosyaya yazar
+func izleCevreSnapshot(ctx context.Context, c client.Client, cev src.Il, reg *registry.Registry, isCB bool) {
+	st := secimTurID(isCB)
+	colNames := reg.Sutunlar(cev.SecimCEVRESIID)
+	sb := SutunBilgi{Names: reg.Adlar(cev.SecimCEVRESIID)}
+	// tek cevre bellege sigar; sutunlari bilmek icin once tum satirlari topla
+	var rows []map[string]any
+	var anahtarlar []string
+	for _, ilce := range src.IlceListesi(ctx, c, cev, st, 0) {
+		q := src.IlceSonucParams(ilce, st)
+		for j, sonuc := range src.SecimSandikSonucListesi(ctx, c, q) {
+			rows = append(rows, sb.addRow(colNames, sonuc))
+			anahtarlar = append(anahtarlar, q.Kimlik(sonuc, j).String())
+		}
+	}
+	izleYaz(ctx, fmt.Sprintf("izle/cevre%d", cev.SecimCEVRESIID), isCB, &sb, rows, anahtarlar)
+}
+
+// izleKapsamSnapshot bir yurt disi kapsaminin tum sandik sonuclarini zaman damgali dosyaya yazar
+func izleKapsamSnapshot[B any](ctx context.Context, c client.Client, s Scope[B], isCB bool) {
+	st := secimTurID(isCB)
+	birimler := s.Birimler(ctx, c, st)
+	bas := s.Basliklar(ctx, c, st, birimler)
+	sb := SutunBilgi{Names: bas.Adlar()}
+	var rows []map[string]any
+	var anahtarlar []string
+	for i, b := range birimler {
+		sutunlar := bas.Sutunlar(i)
+		for _, q := range s.Params(ctx, c, st, b) {
+			for j, sonuc := range src.SecimSandikSonucListesi(ctx, c, q) {
+				rows = append(rows, sb.addRow(sutunlar, sonuc))
+				anahtarlar = append(anahtarlar, q.Kimlik(sonuc, j).String())
+			}
+		}
+	}
+	izleYaz(ctx, "izle/"+s.Bilgi().Ad, isCB, &sb, rows, anahtarlar)
+}
+
+func izleYaz(ctx context.Context, title string, isCB bool, sb *SutunBilgi, rows []map[string]any, anahtarlar []string) {
+	w, closeFile := openFile(ctx, title, isCB)
+	defer closeFile()
+	pc := sb.FprintHeader(w, "izle"+cbPrefix(isCB), skippedColumnsFn(isCB))
+	// ayni sandiklar her turda tekrar yazildigi icin deftere kaydedilmez
+	for i, row := range rows {
+		pc.FprintRow(w, anahtarlar[i], row)
+	}
+}